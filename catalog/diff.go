@@ -0,0 +1,87 @@
+// Package catalog mirrors the game catalog and reports what changed
+package catalog
+
+import (
+	"sort"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+)
+
+// ChangeKind says how a game changed between two syncs
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Modified ChangeKind = "modified"
+)
+
+// Change is one game that differs from the previous snapshot
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	// Game is the current game, or the last known one when Removed
+	Game flows.Game `json:"game"`
+	// Previous is the game as last seen; nil when Added
+	Previous *flows.Game `json:"previous,omitempty"`
+	// Fields lists the JSON names of the fields that changed when Modified
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Diff compares two catalogs. Changes are ordered added, modified, removed,
+// each by game ID.
+func Diff(previous, current []flows.Game) []Change {
+	before := make(map[int]flows.Game, len(previous))
+	for _, g := range previous {
+		before[g.ID] = g
+	}
+	after := make(map[int]flows.Game, len(current))
+	for _, g := range current {
+		after[g.ID] = g
+	}
+
+	var added, modified, removed []Change
+	for id, g := range after {
+		old, ok := before[id]
+		if !ok {
+			added = append(added, Change{Kind: Added, Game: g})
+			continue
+		}
+		if fields := changedFields(old, g); len(fields) > 0 {
+			modified = append(modified, Change{Kind: Modified, Game: g, Previous: &old, Fields: fields})
+		}
+	}
+	for id, g := range before {
+		if _, ok := after[id]; !ok {
+			old := g
+			removed = append(removed, Change{Kind: Removed, Game: g, Previous: &old})
+		}
+	}
+
+	for _, list := range [][]Change{added, modified, removed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Game.ID < list[j].Game.ID })
+	}
+	changes := make([]Change, 0, len(added)+len(modified)+len(removed))
+	changes = append(changes, added...)
+	changes = append(changes, modified...)
+	return append(changes, removed...)
+}
+
+func changedFields(a, b flows.Game) []string {
+	var fields []string
+	if a.Title != b.Title {
+		fields = append(fields, "title")
+	}
+	if a.Producer != b.Producer {
+		fields = append(fields, "producer")
+	}
+	if a.Type != b.Type {
+		fields = append(fields, "type")
+	}
+	if a.ImageURL != b.ImageURL {
+		fields = append(fields, "image_url")
+	}
+	if a.HasDemo != b.HasDemo {
+		fields = append(fields, "has_demo")
+	}
+	return fields
+}
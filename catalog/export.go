@@ -0,0 +1,293 @@
+package catalog
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+)
+
+// ErrUnknownColumn is returned for a column name that is not exportable
+var ErrUnknownColumn = errors.New("unknown column")
+
+// ErrUnknownFormat is returned by ParseFormat for an unsupported format
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Column is one exported field. Value returns a string, int or bool.
+type Column struct {
+	Name  string
+	Value func(g flows.Game) any
+}
+
+var columns = []Column{
+	{"id", func(g flows.Game) any { return g.ID }},
+	{"title", func(g flows.Game) any { return g.Title }},
+	{"producer", func(g flows.Game) any { return g.Producer }},
+	{"type", func(g flows.Game) any { return g.Type }},
+	{"image_url", func(g flows.Game) any { return g.ImageURL }},
+	{"has_demo", func(g flows.Game) any { return g.HasDemo }},
+}
+
+// DefaultColumns returns every exportable column
+func DefaultColumns() []Column {
+	return append([]Column(nil), columns...)
+}
+
+// Columns selects columns by name, in the given order
+func Columns(names ...string) ([]Column, error) {
+	selected := make([]Column, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, c := range columns {
+			if strings.EqualFold(c.Name, strings.TrimSpace(name)) {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+		}
+	}
+	return selected, nil
+}
+
+// Format is an export file format
+type Format string
+
+const (
+	CSV   Format = "csv"
+	JSONL Format = "jsonl"
+	XLSX  Format = "xlsx"
+)
+
+// ParseFormat parses a format name or file extension such as ".csv"
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimPrefix(s, "."))); f {
+	case CSV, JSONL, XLSX:
+		return f, nil
+	case "ndjson":
+		return JSONL, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, s)
+}
+
+// Games adapts a slice to the sequence the exporters read
+func Games(games []flows.Game) iter.Seq2[flows.Game, error] {
+	return func(yield func(flows.Game, error) bool) {
+		for _, g := range games {
+			if !yield(g, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Export streams games to w in the format, with the columns or all columns
+// when none are given. Pass GamesFlow.All to export the live catalog page by
+// page. It returns the number of games written.
+func Export(w io.Writer, format Format, games iter.Seq2[flows.Game, error], cols ...Column) (int, error) {
+	if len(cols) == 0 {
+		cols = columns
+	}
+	switch format {
+	case CSV:
+		return ExportCSV(w, games, cols...)
+	case JSONL:
+		return ExportJSONL(w, games, cols...)
+	case XLSX:
+		return ExportXLSX(w, games, cols...)
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// ExportCSV writes a header row and one row per game. Text that a
+// spreadsheet would run as a formula is prefixed with a quote.
+func ExportCSV(w io.Writer, games iter.Seq2[flows.Game, error], cols ...Column) (int, error) {
+	if len(cols) == 0 {
+		cols = columns
+	}
+	cw := csv.NewWriter(w)
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	row := make([]string, len(cols))
+	for g, err := range games {
+		if err != nil {
+			cw.Flush()
+			return n, err
+		}
+		for i, c := range cols {
+			row[i] = csvCell(c.Value(g))
+		}
+		if err := cw.Write(row); err != nil {
+			return n, err
+		}
+		n++
+	}
+	cw.Flush()
+	return n, cw.Error()
+}
+
+// ExportJSONL writes one JSON object per game and line
+func ExportJSONL(w io.Writer, games iter.Seq2[flows.Game, error], cols ...Column) (int, error) {
+	if len(cols) == 0 {
+		cols = columns
+	}
+	bw := bufio.NewWriter(w)
+	n := 0
+	for g, err := range games {
+		if err != nil {
+			bw.Flush()
+			return n, err
+		}
+		// Build the object by hand to keep the column order
+		bw.WriteByte('{')
+		for i, c := range cols {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			key, _ := json.Marshal(c.Name)
+			value, err := json.Marshal(c.Value(g))
+			if err != nil {
+				return n, err
+			}
+			bw.Write(key)
+			bw.WriteByte(':')
+			bw.Write(value)
+		}
+		if _, err := bw.WriteString("}\n"); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, bw.Flush()
+}
+
+// ExportXLSX writes a single-sheet Excel workbook. Rows are streamed into
+// the archive, so the catalog is never held in memory. If games fails, the
+// workbook is still closed with the rows written so far and the error is
+// returned.
+func ExportXLSX(w io.Writer, games iter.Seq2[flows.Game, error], cols ...Column) (int, error) {
+	if len(cols) == 0 {
+		cols = columns
+	}
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return 0, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return 0, err
+	}
+	sheet := bufio.NewWriter(f)
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]any, len(cols))
+	for i, c := range cols {
+		header[i] = c.Name
+	}
+	writeXLSXRow(sheet, 1, header)
+
+	n := 0
+	values := make([]any, len(cols))
+	var iterErr error
+	for g, err := range games {
+		if err != nil {
+			iterErr = err
+			break
+		}
+		for i, c := range cols {
+			values[i] = c.Value(g)
+		}
+		writeXLSXRow(sheet, n+2, values)
+		n++
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+	if err := sheet.Flush(); err != nil {
+		return n, err
+	}
+	if err := zw.Close(); err != nil {
+		return n, err
+	}
+	return n, iterErr
+}
+
+func csvCell(v any) string {
+	s := fmt.Sprint(v)
+	if str, ok := v.(string); ok && str != "" && strings.ContainsRune("=+-@\t\r", rune(str[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func writeXLSXRow(w *bufio.Writer, row int, values []any) {
+	fmt.Fprintf(w, `<row r="%d">`, row)
+	for i, v := range values {
+		ref := cellColumn(i) + strconv.Itoa(row)
+		switch v := v.(type) {
+		case int:
+			fmt.Fprintf(w, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(w, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		default:
+			fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is><t>`, ref)
+			xml.EscapeText(w, []byte(fmt.Sprint(v)))
+			w.WriteString(`</t></is></c>`)
+		}
+	}
+	w.WriteString(`</row>`)
+}
+
+// cellColumn converts a 0-based column index to its letters: A, B, ... AA
+func cellColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Games" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
@@ -0,0 +1,335 @@
+package catalog
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+)
+
+// Facets a query can filter and count by
+const (
+	FacetProducer = "producer"
+	FacetType     = "type"
+	FacetDemo     = "has_demo"
+)
+
+var facetFields = map[string]func(flows.Game) string{
+	FacetProducer: func(g flows.Game) string { return g.Producer },
+	FacetType:     func(g flows.Game) string { return g.Type },
+	FacetDemo:     func(g flows.Game) string { return strconv.FormatBool(g.HasDemo) },
+}
+
+// Query is a search over an Index
+type Query struct {
+	// Text matches title words. Small typos are tolerated, and the last word
+	// also matches as a prefix for search-as-you-type. Empty matches every
+	// game.
+	Text string
+	// Filters keeps games whose facet has one of the listed values, ignoring
+	// case, e.g. {FacetProducer: {"Pragmatic", "NetEnt"}, FacetDemo: {"true"}}
+	Filters map[string][]string
+	// Exact turns off typo tolerance
+	Exact bool
+	// Offset and Limit page the hits; Limit defaults to 20
+	Offset int
+	Limit  int
+}
+
+// Hit is a matching game
+type Hit struct {
+	Game  flows.Game
+	Score float64
+}
+
+// FacetValue is the number of hits with a facet value
+type FacetValue struct {
+	Value string
+	Count int
+}
+
+// Result of a search
+type Result struct {
+	Hits []Hit
+	// Total is the number of matches before paging
+	Total int
+	// Facets counts matches per value of each facet. A facet's counts ignore
+	// the query's own filter on it, so other values can still be offered.
+	Facets map[string][]FacetValue
+}
+
+// Index searches a catalog in memory. Build it from Catalog.Games or a
+// Syncer's fetch and rebuild it when the catalog changes. An Index is
+// read-only and safe for concurrent use.
+type Index struct {
+	games []flows.Game
+	words [][]string
+	// postings maps a word to the games whose title contains it
+	postings map[string][]int
+	// vocabulary is every indexed word, sorted for prefix lookups
+	vocabulary []string
+}
+
+// NewIndex indexes games
+func NewIndex(games []flows.Game) *Index {
+	idx := &Index{
+		games:    games,
+		words:    make([][]string, len(games)),
+		postings: make(map[string][]int),
+	}
+	for i, g := range games {
+		words := tokenize(g.Title)
+		idx.words[i] = words
+		seen := make(map[string]bool, len(words))
+		for _, w := range words {
+			if seen[w] {
+				continue
+			}
+			seen[w] = true
+			idx.postings[w] = append(idx.postings[w], i)
+		}
+	}
+	idx.vocabulary = make([]string, 0, len(idx.postings))
+	for w := range idx.postings {
+		idx.vocabulary = append(idx.vocabulary, w)
+	}
+	sort.Strings(idx.vocabulary)
+	return idx
+}
+
+// Len returns the number of indexed games
+func (idx *Index) Len() int {
+	return len(idx.games)
+}
+
+// Search runs a query
+func (idx *Index) Search(q Query) Result {
+	scores := idx.match(q.Text, q.Exact)
+
+	// Games passing every filter, and per facet those passing all the others
+	var hits []Hit
+	counts := make(map[string]map[string]int, len(facetFields))
+	for facet := range facetFields {
+		counts[facet] = make(map[string]int)
+	}
+	for i, g := range idx.games {
+		score := 1.0
+		if scores != nil {
+			s, ok := scores[i]
+			if !ok {
+				continue
+			}
+			score = s
+		}
+
+		failed := ""
+		for facet, values := range q.Filters {
+			if !matchesFilter(g, facet, values) {
+				if failed != "" {
+					failed = "\x00"
+					break
+				}
+				failed = facet
+			}
+		}
+		for facet, field := range facetFields {
+			if failed == "" || failed == facet {
+				counts[facet][field(g)]++
+			}
+		}
+		if failed == "" {
+			hits = append(hits, Hit{Game: g, Score: score})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Game.Title < hits[j].Game.Title
+	})
+
+	result := Result{Total: len(hits), Facets: make(map[string][]FacetValue, len(counts))}
+	for facet, values := range counts {
+		list := make([]FacetValue, 0, len(values))
+		for v, n := range values {
+			list = append(list, FacetValue{Value: v, Count: n})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Count != list[j].Count {
+				return list[i].Count > list[j].Count
+			}
+			return list[i].Value < list[j].Value
+		})
+		result.Facets[facet] = list
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	start := min(max(q.Offset, 0), len(hits))
+	end := min(start+limit, len(hits))
+	result.Hits = hits[start:end]
+	return result
+}
+
+// Suggest completes a partly typed title, returning up to limit titles
+func (idx *Index) Suggest(text string, limit int) []string {
+	res := idx.Search(Query{Text: text, Exact: true, Limit: limit})
+	titles := make([]string, 0, len(res.Hits))
+	seen := make(map[string]bool)
+	for _, h := range res.Hits {
+		if !seen[h.Game.Title] {
+			seen[h.Game.Title] = true
+			titles = append(titles, h.Game.Title)
+		}
+	}
+	return titles
+}
+
+// match scores the games whose titles match every word of text; nil means
+// text is empty and everything matches
+func (idx *Index) match(text string, exact bool) map[int]float64 {
+	words := tokenize(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var scores map[int]float64
+	for n, word := range words {
+		last := n == len(words)-1
+		wordScores := make(map[int]float64)
+		add := func(w string, score float64) {
+			for _, i := range idx.postings[w] {
+				if score > wordScores[i] {
+					wordScores[i] = score
+				}
+			}
+		}
+
+		add(word, 3)
+		if last {
+			for _, w := range idx.withPrefix(word) {
+				if w != word {
+					add(w, 2)
+				}
+			}
+		}
+		if !exact {
+			if edits := maxEdits(word); edits > 0 {
+				runes := []rune(word)
+				for _, w := range idx.vocabulary {
+					if d := editDistance(runes, w, edits); d > 0 && d <= edits {
+						add(w, 1/float64(1+d))
+					}
+				}
+			}
+		}
+
+		if scores == nil {
+			scores = wordScores
+			continue
+		}
+		for i, s := range scores {
+			if ws, ok := wordScores[i]; ok {
+				scores[i] = s + ws
+			} else {
+				delete(scores, i)
+			}
+		}
+	}
+
+	// Prefer titles that start with the query and shorter titles
+	for i := range scores {
+		title := idx.words[i]
+		if len(title) > 0 && strings.HasPrefix(title[0], words[0]) {
+			scores[i] += 1
+		}
+		scores[i] -= float64(len(title)) * 0.01
+	}
+	return scores
+}
+
+// withPrefix returns the indexed words starting with prefix
+func (idx *Index) withPrefix(prefix string) []string {
+	start := sort.SearchStrings(idx.vocabulary, prefix)
+	end := start
+	for end < len(idx.vocabulary) && strings.HasPrefix(idx.vocabulary[end], prefix) {
+		end++
+	}
+	return idx.vocabulary[start:end]
+}
+
+func matchesFilter(g flows.Game, facet string, values []string) bool {
+	field, ok := facetFields[facet]
+	if !ok || len(values) == 0 {
+		return true
+	}
+	v := field(g)
+	for _, want := range values {
+		if strings.EqualFold(v, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenize lowercases s and splits it into words of letters and digits
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// maxEdits is the number of typos tolerated in a word of its length
+func maxEdits(word string) int {
+	switch n := len([]rune(word)); {
+	case n <= 3:
+		return 0
+	case n <= 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the Damerau-Levenshtein distance between a and b
+// (adjacent transpositions count as one edit), or limit+1 once it is known
+// to exceed limit
+func editDistance(ra []rune, b string, limit int) int {
+	if d := len(ra) - utf8.RuneCountInString(b); d > limit || -d > limit {
+		return limit + 1
+	}
+	rb := []rune(b)
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			best = min(best, cur[j])
+		}
+		if best > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
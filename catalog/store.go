@@ -0,0 +1,109 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+)
+
+// Snapshot is the catalog as of a sync
+type Snapshot struct {
+	TakenAt time.Time    `json:"taken_at"`
+	Games   []flows.Game `json:"games"`
+}
+
+// SnapshotStore persists the snapshot the next sync is compared against
+type SnapshotStore interface {
+	// Load returns false when no snapshot has been saved yet
+	Load(ctx context.Context) (Snapshot, bool, error)
+	Save(ctx context.Context, snapshot Snapshot) error
+}
+
+// MemorySnapshotStore keeps the snapshot in memory
+type MemorySnapshotStore struct {
+	mu       sync.Mutex
+	snapshot *Snapshot
+}
+
+// NewMemorySnapshotStore creates an empty in-memory store
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{}
+}
+
+// Load implements SnapshotStore
+func (s *MemorySnapshotStore) Load(ctx context.Context) (Snapshot, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snapshot == nil {
+		return Snapshot{}, false, nil
+	}
+	return *s.snapshot, true, nil
+}
+
+// Save implements SnapshotStore
+func (s *MemorySnapshotStore) Save(ctx context.Context, snapshot Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot = &snapshot
+	return nil
+}
+
+// FileSnapshotStore keeps the snapshot in a JSON file, replaced atomically
+// on save
+type FileSnapshotStore struct {
+	path string
+}
+
+// NewFileSnapshotStore creates a store at path; the file is created on the
+// first save
+func NewFileSnapshotStore(path string) *FileSnapshotStore {
+	return &FileSnapshotStore{path: path}
+}
+
+// Load implements SnapshotStore
+func (s *FileSnapshotStore) Load(ctx context.Context) (Snapshot, bool, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, false, err
+	}
+	return snapshot, true, nil
+}
+
+// Save implements SnapshotStore
+func (s *FileSnapshotStore) Save(ctx context.Context, snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,241 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+	"github.com/iplaygamesai/sdk-wrapper-go/pagination"
+)
+
+// ErrIncompleteCatalog is returned by the default Fetch when the games
+// listed do not add up to the total the API reported, as happens when the
+// catalog changes while it is being paged through
+var ErrIncompleteCatalog = errors.New("incomplete catalog")
+
+// Options configures a Syncer
+type Options struct {
+	// Params filters the mirrored catalog; PerPage defaults to 100
+	Params flows.ListParams
+	// Store defaults to an in-memory store
+	Store SnapshotStore
+	// OnChange is called for each change, in Diff order. An error stops the
+	// sync before the snapshot is saved, so the next run reports the same
+	// changes again.
+	OnChange func(ctx context.Context, change Change) error
+	// Interval between syncs in Run; defaults to 1 hour
+	Interval time.Duration
+	// OnError is called when a sync in Run fails
+	OnError func(err error)
+	// Fetch loads the current catalog; defaults to walking GamesFlow.List,
+	// failing with ErrIncompleteCatalog when a game was skipped
+	Fetch func(ctx context.Context) ([]flows.Game, error)
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// Report describes one sync
+type Report struct {
+	DryRun   bool
+	Started  time.Time
+	Finished time.Time
+	// Previous is when the compared snapshot was taken; zero on the first
+	// sync, when every game is reported as added
+	Previous time.Time
+	Total    int
+	Changes  []Change
+}
+
+// Count returns the number of changes of the given kind
+func (r *Report) Count(kind ChangeKind) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// HasChanges reports whether anything changed
+func (r *Report) HasChanges() bool {
+	return len(r.Changes) > 0
+}
+
+// WriteTo writes a human-readable report, one line per change
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	mode := "sync"
+	if r.DryRun {
+		mode = "dry run"
+	}
+	fmt.Fprintf(&b, "catalog %s at %s: %d games, %d added, %d modified, %d removed\n",
+		mode, r.Started.UTC().Format(time.RFC3339), r.Total, r.Count(Added), r.Count(Modified), r.Count(Removed))
+	for _, c := range r.Changes {
+		switch c.Kind {
+		case Modified:
+			fmt.Fprintf(&b, "  ~ %d %s (%s)\n", c.Game.ID, c.Game.Title, strings.Join(c.Fields, ", "))
+		case Added:
+			fmt.Fprintf(&b, "  + %d %s\n", c.Game.ID, c.Game.Title)
+		case Removed:
+			fmt.Fprintf(&b, "  - %d %s\n", c.Game.ID, c.Game.Title)
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Syncer mirrors the game catalog, reporting the games added, removed and
+// modified since the last sync
+type Syncer struct {
+	fetch    func(ctx context.Context) ([]flows.Game, error)
+	store    SnapshotStore
+	onChange func(ctx context.Context, change Change) error
+	interval time.Duration
+	onError  func(error)
+	now      func() time.Time
+}
+
+// NewSyncer creates a syncer over the games flow
+func NewSyncer(games *flows.GamesFlow, opts Options) *Syncer {
+	if opts.Store == nil {
+		opts.Store = NewMemorySnapshotStore()
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.Fetch == nil {
+		params := opts.Params
+		params.Page, params.Cursor = 0, ""
+		if params.PerPage <= 0 {
+			params.PerPage = 100
+		}
+		opts.Fetch = func(ctx context.Context) ([]flows.Game, error) {
+			return fetchAll(ctx, games, params)
+		}
+	}
+	return &Syncer{
+		fetch:    opts.Fetch,
+		store:    opts.Store,
+		onChange: opts.OnChange,
+		interval: opts.Interval,
+		onError:  opts.OnError,
+		now:      opts.Now,
+	}
+}
+
+// Sync fetches the catalog, calls OnChange for each change and saves the
+// new snapshot
+func (s *Syncer) Sync(ctx context.Context) (*Report, error) {
+	return s.sync(ctx, false)
+}
+
+// DryRun reports what Sync would change without calling OnChange or saving
+// the snapshot
+func (s *Syncer) DryRun(ctx context.Context) (*Report, error) {
+	return s.sync(ctx, true)
+}
+
+// Run syncs every Interval until ctx is done. Failures are reported to
+// OnError and retried at the next interval.
+func (s *Syncer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Sync(ctx); err != nil && s.onError != nil && ctx.Err() == nil {
+			s.onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Syncer) sync(ctx context.Context, dryRun bool) (*Report, error) {
+	report := &Report{DryRun: dryRun, Started: s.now()}
+
+	current, err := s.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch catalog: %w", err)
+	}
+	previous, ok, err := s.store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+	if ok {
+		report.Previous = previous.TakenAt
+	}
+	report.Total = len(current)
+	report.Changes = Diff(previous.Games, current)
+
+	if !dryRun {
+		if s.onChange != nil {
+			for _, change := range report.Changes {
+				if err := s.onChange(ctx, change); err != nil {
+					return report, fmt.Errorf("game %d %s: %w", change.Game.ID, change.Kind, err)
+				}
+			}
+		}
+		if err := s.store.Save(ctx, Snapshot{TakenAt: report.Started, Games: current}); err != nil {
+			return report, fmt.Errorf("save snapshot: %w", err)
+		}
+	}
+	report.Finished = s.now()
+	return report, nil
+}
+
+// fetchAll walks every page of params. Offset pages shift when a game is
+// added or removed mid-walk, repeating or skipping games, so duplicates are
+// dropped and the result must match a total every page agreed on.
+func fetchAll(ctx context.Context, games *flows.GamesFlow, params flows.ListParams) ([]flows.Game, error) {
+	total := -1
+	fetch := func(ctx context.Context, req pagination.Request) (pagination.Page[flows.Game], error) {
+		p := params
+		p.Page, p.Cursor = req.Page, req.Cursor
+		resp := games.List(ctx, p)
+		if !resp.Success {
+			return pagination.Page[flows.Game]{}, errors.New(resp.Error)
+		}
+		if total >= 0 && resp.Meta.Total != total {
+			return pagination.Page[flows.Game]{}, fmt.Errorf("%w: total changed from %d to %d while listing", ErrIncompleteCatalog, total, resp.Meta.Total)
+		}
+		total = resp.Meta.Total
+		page := pagination.Page[flows.Game]{Items: resp.Games}
+		switch {
+		case resp.Meta.NextCursor != "":
+			page.Next = &pagination.Request{Cursor: resp.Meta.NextCursor}
+		case resp.Meta.CurrentPage > 0 && resp.Meta.CurrentPage < resp.Meta.LastPage:
+			page.Next = &pagination.Request{Page: resp.Meta.CurrentPage + 1}
+		}
+		return page, nil
+	}
+
+	listed, err := pagination.Collect(pagination.All(ctx, pagination.Request{}, fetch))
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]int, len(listed))
+	current := make([]flows.Game, 0, len(listed))
+	for _, g := range listed {
+		if i, ok := seen[g.ID]; ok {
+			// The later copy is the fresher one
+			current[i] = g
+			continue
+		}
+		seen[g.ID] = len(current)
+		current = append(current, g)
+	}
+	if len(current) != total {
+		return nil, fmt.Errorf("%w: listed %d of %d games", ErrIncompleteCatalog, len(current), total)
+	}
+	return current, nil
+}
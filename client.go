@@ -4,6 +4,8 @@ package iplaygames
 import (
 	apiclient "github.com/iplaygamesai/api-client-go"
 	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+	"github.com/iplaygamesai/sdk-wrapper-go/freespins"
+	"github.com/iplaygamesai/sdk-wrapper-go/responsible"
 	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
 )
 
@@ -14,6 +16,15 @@ type ClientOptions struct {
 	WebhookSecret string
 	Timeout       int
 	Debug         bool
+
+	// Guard, if set, checks responsible-gambling policies before sessions
+	// and multi-sessions start. Pass the same guard to webhooks.RouterOptions
+	// to check bets too.
+	Guard *responsible.Guard
+
+	// Preflight checks game metadata before sessions start, refusing games
+	// the player's currency, country or device cannot launch
+	Preflight bool
 }
 
 // Client is the main entry point for the IPlayGames SDK
@@ -22,9 +33,12 @@ type Client struct {
 	apiClient     *apiclient.APIClient
 	webhookSecret string
 	baseURL       string
+	guard         *responsible.Guard
+	preflight     bool
 
 	// Lazy-loaded flows
 	gamesFlow           *flows.GamesFlow
+	producersFlow       *flows.ProducersFlow
 	sessionsFlow        *flows.SessionsFlow
 	multiSessionFlow    *flows.MultiSessionFlow
 	jackpotFlow         *flows.JackpotFlow
@@ -32,6 +46,7 @@ type Client struct {
 	jackpotWidgetFlow   *flows.JackpotWidgetFlow
 	promotionWidgetFlow *flows.PromotionWidgetFlow
 	webhookHandler      *webhooks.Handler
+	freespinTracker     *freespins.Tracker
 }
 
 // NewClient creates a new IPlayGames SDK client
@@ -56,6 +71,8 @@ func NewClient(opts ClientOptions) (*Client, error) {
 		apiClient:     apiClient,
 		webhookSecret: opts.WebhookSecret,
 		baseURL:       baseURL,
+		guard:         opts.Guard,
+		preflight:     opts.Preflight,
 	}, nil
 }
 
@@ -77,18 +94,41 @@ func (c *Client) Games() *flows.GamesFlow {
 	return c.gamesFlow
 }
 
+// Producers returns the producers flow
+func (c *Client) Producers() *flows.ProducersFlow {
+	if c.producersFlow == nil {
+		c.producersFlow = flows.NewProducersFlow(c.apiClient)
+	}
+	return c.producersFlow
+}
+
 // Sessions returns the sessions flow
 func (c *Client) Sessions() *flows.SessionsFlow {
 	if c.sessionsFlow == nil {
 		c.sessionsFlow = flows.NewSessionsFlow(c.apiClient)
+		c.sessionsFlow.SetFreespinTracker(c.Freespins())
+		c.sessionsFlow.SetGuard(c.guard)
+		if c.preflight {
+			c.sessionsFlow.SetPreflight(c.Games())
+		}
 	}
 	return c.sessionsFlow
 }
 
+// Freespins returns the freespin campaign tracker. Grants made through
+// Sessions().Start are recorded in it automatically.
+func (c *Client) Freespins() *freespins.Tracker {
+	if c.freespinTracker == nil {
+		c.freespinTracker = freespins.NewTracker(freespins.Options{})
+	}
+	return c.freespinTracker
+}
+
 // MultiSession returns the multi-session flow
 func (c *Client) MultiSession() *flows.MultiSessionFlow {
 	if c.multiSessionFlow == nil {
 		c.multiSessionFlow = flows.NewMultiSessionFlow(c.apiClient)
+		c.multiSessionFlow.SetGuard(c.guard)
 	}
 	return c.multiSessionFlow
 }
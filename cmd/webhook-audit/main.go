@@ -0,0 +1,99 @@
+// Command webhook-audit verifies the hash chain of a webhook audit log and
+// exports its records as JSON Lines.
+//
+// Usage:
+//
+//	webhook-audit -log audit.jsonl
+//	webhook-audit -log audit.jsonl -export out.jsonl -from 2024-01-01T00:00:00Z -player player_456
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
+)
+
+func main() {
+	path := flag.String("log", "", "audit log written by webhooks.FileAuditLog (required)")
+	export := flag.String("export", "", "write matching records to this file as JSON Lines; - for stdout")
+	from := flag.String("from", "", "export records at or after this RFC 3339 time")
+	to := flag.String("to", "", "export records before this RFC 3339 time")
+	player := flag.String("player", "", "export only this player's records")
+	typ := flag.String("type", "", "export only this webhook type")
+	head := flag.String("head", "", "expected hash of the last record, to detect truncation")
+	flag.Parse()
+
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	in, err := os.Open(*path)
+	if err != nil {
+		fail(err)
+	}
+	defer in.Close()
+
+	if *export == "" {
+		n, last, err := webhooks.VerifyAuditLog(in)
+		report(err)
+		if *head != "" && last != *head {
+			fmt.Fprintf(os.Stderr, "FAIL: last record hash %s, expected %s (log truncated?)\n", last, *head)
+			os.Exit(1)
+		}
+		fmt.Printf("OK: %d records verified, head %s\n", n, last)
+		return
+	}
+
+	filter := webhooks.AuditFilter{PlayerID: *player, Type: *typ}
+	if filter.From, err = parseTime(*from); err != nil {
+		fail(err)
+	}
+	if filter.To, err = parseTime(*to); err != nil {
+		fail(err)
+	}
+
+	var out io.Writer = os.Stdout
+	if *export != "-" {
+		f, err := os.Create(*export)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		out = f
+	}
+	n, err := webhooks.ExportAuditLog(out, in, filter)
+	if err != nil {
+		report(err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d records\n", n)
+}
+
+func report(err error) {
+	if err == nil {
+		return
+	}
+	var chain *webhooks.AuditChainError
+	if errors.As(err, &chain) {
+		fmt.Fprintf(os.Stderr, "FAIL: %v\n", err)
+		os.Exit(1)
+	}
+	fail(err)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(2)
+}
@@ -0,0 +1,64 @@
+// Command webhook-conformance runs the wallet conformance suite against an
+// operator webhook endpoint.
+//
+// Usage:
+//
+//	webhook-conformance -url http://localhost:8080/webhooks/gamehub -player player_456 -currency USD
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks/conformance"
+)
+
+func main() {
+	cfg := conformance.Config{}
+	flag.StringVar(&cfg.URL, "url", "", "webhook endpoint URL (required)")
+	flag.StringVar(&cfg.Secret, "secret", os.Getenv("GAMEHUB_WEBHOOK_SECRET"), "webhook secret (default $GAMEHUB_WEBHOOK_SECRET)")
+	flag.StringVar(&cfg.SignatureHeader, "header", "X-Signature", "signature header name")
+	flag.StringVar(&cfg.PlayerID, "player", "", "existing, funded player ID (required)")
+	flag.StringVar(&cfg.Currency, "currency", "USD", "currency of the player's balance")
+	flag.StringVar(&cfg.UnknownPlayerID, "unknown-player", "", "player ID that must not exist")
+	flag.Int64Var(&cfg.BetAmount, "bet", 100, "bet amount in minor units")
+	flag.Int64Var(&cfg.WinAmount, "win", 250, "win amount in minor units")
+	timeout := flag.Duration("timeout", 2*time.Minute, "overall timeout")
+	list := flag.Bool("list", false, "list the checks and exit")
+	flag.Parse()
+
+	if *list {
+		for _, c := range conformance.Checks() {
+			fmt.Printf("%-30s %s\n", c.Name, c.Description)
+		}
+		return
+	}
+	if cfg.URL == "" || cfg.PlayerID == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	results, err := conformance.Run(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+
+	failed := 0
+	for _, r := range results {
+		fmt.Print(r)
+		if !r.Passed() {
+			failed++
+		}
+	}
+	fmt.Printf("\n%d/%d checks passed\n", len(results)-failed, len(results))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,122 @@
+package flows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrGameUnavailable is matched by every *UnavailableError
+var ErrGameUnavailable = errors.New("game unavailable")
+
+// ErrorCodeGameUnavailable is the SessionResponse.ErrorCode of a launch the
+// preflight check refused
+const ErrorCodeGameUnavailable = "GAME_UNAVAILABLE"
+
+// UnavailableReason says why a game cannot be launched
+type UnavailableReason string
+
+const (
+	ReasonGameNotFound         UnavailableReason = "game_not_found"
+	ReasonCurrencyNotSupported UnavailableReason = "currency_not_supported"
+	ReasonCountryRestricted    UnavailableReason = "country_restricted"
+	ReasonDeviceNotSupported   UnavailableReason = "device_not_supported"
+	ReasonDemoNotSupported     UnavailableReason = "demo_not_supported"
+)
+
+// UnavailableError reports a game that cannot be launched for a player
+type UnavailableError struct {
+	GameID int
+	Reason UnavailableReason
+	// Value is the currency, country or device that was refused
+	Value string
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("game %d unavailable: %s", e.GameID, e.Reason)
+	}
+	return fmt.Sprintf("game %d unavailable: %s (%s)", e.GameID, e.Reason, e.Value)
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrGameUnavailable
+}
+
+// Availability is the result of GamesFlow.IsAvailable
+type Availability struct {
+	GameID    int               `json:"game_id"`
+	Available bool              `json:"available"`
+	Reason    UnavailableReason `json:"reason,omitempty"`
+	// Value is the currency, country or device that was refused
+	Value string `json:"value,omitempty"`
+	// Game is the metadata the check was made against
+	Game *GameDetails `json:"game,omitempty"`
+}
+
+// Err returns an *UnavailableError when the game is unavailable
+func (a Availability) Err() error {
+	if a.Available {
+		return nil
+	}
+	return &UnavailableError{GameID: a.GameID, Reason: a.Reason, Value: a.Value}
+}
+
+// CheckAvailability checks game metadata against a player's currency,
+// country and device, returning an *UnavailableError for the first rule
+// that fails. Empty values skip their check; demo also requires demo
+// support.
+func CheckAvailability(game *GameDetails, currency, country, device string, demo bool) error {
+	currency, country = strings.ToUpper(currency), strings.ToUpper(country)
+	switch {
+	case currency != "" && !game.SupportsCurrency(currency):
+		return &UnavailableError{GameID: game.ID, Reason: ReasonCurrencyNotSupported, Value: currency}
+	case country != "" && game.RestrictedIn(country):
+		return &UnavailableError{GameID: game.ID, Reason: ReasonCountryRestricted, Value: country}
+	case device != "" && !game.SupportsDevice(device):
+		return &UnavailableError{GameID: game.ID, Reason: ReasonDeviceNotSupported, Value: device}
+	case demo && !game.HasDemo:
+		return &UnavailableError{GameID: game.ID, Reason: ReasonDemoNotSupported}
+	}
+	return nil
+}
+
+// IsAvailable checks whether a game can be launched for a player with the
+// currency, in the country and on the device. Empty values skip their
+// check, and a game that does not exist is unavailable with
+// ReasonGameNotFound. Metadata comes from the catalog cache when SetCatalog
+// is used. The error is only set when the metadata could not be fetched.
+func (f *GamesFlow) IsAvailable(ctx context.Context, gameID int, currency, country, device string) (Availability, error) {
+	return f.availability(ctx, gameID, currency, country, device, false)
+}
+
+func (f *GamesFlow) availability(ctx context.Context, gameID int, currency, country, device string, demo bool) (Availability, error) {
+	games, errs := f.getMany(ctx, []int{gameID}, f.get)
+	game, ok := games[gameID]
+	if !ok {
+		if errors.Is(errs[gameID], ErrGameNotFound) {
+			return Availability{GameID: gameID, Reason: ReasonGameNotFound}, nil
+		}
+		return Availability{GameID: gameID}, errs[gameID]
+	}
+
+	var unavailable *UnavailableError
+	if errors.As(CheckAvailability(game, currency, country, device, demo), &unavailable) {
+		return Availability{GameID: gameID, Reason: unavailable.Reason, Value: unavailable.Value, Game: game}, nil
+	}
+	return Availability{GameID: gameID, Available: true, Game: game}, nil
+}
+
+// preflight returns the reason the game's metadata rules out a launch. A
+// failed metadata lookup lets the launch through for the API to decide.
+func (f *SessionsFlow) preflight(ctx context.Context, gameID int, currency, country, device string, demo bool) error {
+	if f.games == nil {
+		return nil
+	}
+	a, err := f.games.availability(ctx, gameID, currency, country, device, demo)
+	if err != nil {
+		return nil
+	}
+	return a.Err()
+}
@@ -0,0 +1,392 @@
+package flows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotModified is returned by a CatalogSource when the catalog has not
+// changed since the given ETag
+var ErrNotModified = errors.New("catalog not modified")
+
+// ErrCatalogUnavailable is returned when the catalog has never been loaded
+// and cannot be fetched
+var ErrCatalogUnavailable = errors.New("game catalog unavailable")
+
+// ErrUnsupportedParams is returned by Catalog.List for ListParams the cache
+// cannot answer; cached games carry no producer ID, provider or cursor
+var ErrUnsupportedParams = errors.New("list params not supported by the catalog cache")
+
+// CatalogSource loads the full catalog. etag is the tag of the cached copy,
+// empty on the first load; a source that supports conditional requests
+// returns ErrNotModified when nothing changed.
+type CatalogSource func(ctx context.Context, etag string) (games []Game, newETag string, err error)
+
+// CatalogOptions configures a Catalog
+type CatalogOptions struct {
+	// Params filters the cached catalog; Page and Cursor are ignored and
+	// PerPage defaults to 100
+	Params ListParams
+	// TTL is how long a loaded catalog is served before it is refreshed;
+	// defaults to 5 minutes
+	TTL time.Duration
+	// RefreshInterval is how often Run refreshes; defaults to TTL
+	RefreshInterval time.Duration
+	// MaxStale is how long past its TTL the catalog is still served when
+	// refreshing fails; 0 serves it indefinitely
+	MaxStale time.Duration
+	// Source defaults to listing games through the API
+	Source CatalogSource
+	// OnError is called when a refresh fails
+	OnError func(err error)
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// CatalogStats reports cache activity
+type CatalogStats struct {
+	Hits int64
+	// Misses are lookups that had to wait for a refresh
+	Misses int64
+	// StaleHits are lookups served expired data while a refresh was running
+	// or had failed
+	StaleHits     int64
+	Refreshes     int64
+	NotModified   int64
+	RefreshErrors int64
+	// DetailHits and DetailMisses count GetMany lookups of cached details
+	DetailHits   int64
+	DetailMisses int64
+	Size         int
+	ETag         string
+	LastRefresh  time.Time
+	LastError    error
+}
+
+// Catalog keeps the game catalog in memory so lobby pages don't call the
+// API on every view. Expired data is refreshed on the next lookup, or ahead
+// of time by Run, and served stale while a refresh runs or the API is
+// unavailable. A Catalog is safe for concurrent use.
+type Catalog struct {
+	source   CatalogSource
+	ttl      time.Duration
+	interval time.Duration
+	maxStale time.Duration
+	onError  func(error)
+	now      func() time.Time
+	// complete is set when Params do not filter, so a game missing from
+	// the catalog does not exist
+	complete bool
+
+	// refreshMu lets one refresh run at a time
+	refreshMu sync.Mutex
+
+	mu        sync.RWMutex
+	games     []Game
+	byID      map[int]Game
+	loaded    bool
+	etag      string
+	fetchedAt time.Time
+	stats     CatalogStats
+	// details caches GameDetails fetched by GamesFlow.GetMany
+	details map[int]cachedDetails
+}
+
+type cachedDetails struct {
+	game      *GameDetails
+	fetchedAt time.Time
+}
+
+// NewCatalog creates a catalog cache of the games flow. It is empty until
+// the first lookup, Refresh or Run.
+func NewCatalog(flow *GamesFlow, opts CatalogOptions) *Catalog {
+	if opts.TTL <= 0 {
+		opts.TTL = 5 * time.Minute
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = opts.TTL
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	p := opts.Params
+	if opts.Source == nil {
+		params := opts.Params
+		params.Page, params.Cursor = 0, ""
+		if params.PerPage <= 0 {
+			params.PerPage = 100
+		}
+		opts.Source = flow.catalogSource(params)
+	}
+	return &Catalog{
+		source:   opts.Source,
+		ttl:      opts.TTL,
+		interval: opts.RefreshInterval,
+		maxStale: opts.MaxStale,
+		onError:  opts.OnError,
+		now:      opts.Now,
+		byID:     make(map[int]Game),
+		details:  make(map[int]cachedDetails),
+		complete: p.Search == "" && p.ProducerID == 0 && p.Provider == "" && p.Type == "",
+	}
+}
+
+// Catalog creates a catalog cache of this flow
+func (f *GamesFlow) Catalog(opts CatalogOptions) *Catalog {
+	return NewCatalog(f, opts)
+}
+
+// Games returns the whole catalog. The slice is shared and must not be
+// modified.
+func (c *Catalog) Games(ctx context.Context) ([]Game, error) {
+	if err := c.ensure(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.games, nil
+}
+
+// Game looks up a single game by ID
+func (c *Catalog) Game(ctx context.Context, gameID int) (Game, bool, error) {
+	if err := c.ensure(ctx); err != nil {
+		return Game{}, false, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	g, ok := c.byID[gameID]
+	return g, ok, nil
+}
+
+// List answers a GamesFlow.List call from the cache. Search matches titles
+// and Type the game type, both ignoring case. ProducerID, Provider and
+// Cursor fail with ErrUnsupportedParams; use GamesFlow.List for those.
+func (c *Catalog) List(ctx context.Context, params ListParams) GamesListResponse {
+	var unsupported []string
+	if params.ProducerID != 0 {
+		unsupported = append(unsupported, "ProducerID")
+	}
+	if params.Provider != "" {
+		unsupported = append(unsupported, "Provider")
+	}
+	if params.Cursor != "" {
+		unsupported = append(unsupported, "Cursor")
+	}
+	var (
+		games []Game
+		err   error
+	)
+	if len(unsupported) > 0 {
+		err = fmt.Errorf("%w: %s", ErrUnsupportedParams, strings.Join(unsupported, ", "))
+	} else {
+		games, err = c.Games(ctx)
+	}
+	if err != nil {
+		return GamesListResponse{
+			Success: false,
+			Error:   err.Error(),
+			Games:   []Game{},
+			Meta:    PaginationMeta{Total: 0},
+		}
+	}
+
+	matched := make([]Game, 0)
+	search := strings.ToLower(params.Search)
+	for _, g := range games {
+		if search != "" && !strings.Contains(strings.ToLower(g.Title), search) {
+			continue
+		}
+		if params.Type != "" && !strings.EqualFold(g.Type, params.Type) {
+			continue
+		}
+		matched = append(matched, g)
+	}
+
+	perPage := params.PerPage
+	if perPage <= 0 {
+		perPage = 15
+	}
+	page := params.Page
+	if page <= 0 {
+		page = 1
+	}
+	lastPage := (len(matched) + perPage - 1) / perPage
+	if lastPage == 0 {
+		lastPage = 1
+	}
+	start := min((page-1)*perPage, len(matched))
+	end := min(start+perPage, len(matched))
+
+	return GamesListResponse{
+		Success: true,
+		Games:   matched[start:end],
+		Meta: PaginationMeta{
+			Total:       len(matched),
+			PerPage:     perPage,
+			CurrentPage: page,
+			LastPage:    lastPage,
+		},
+	}
+}
+
+// Refresh reloads the catalog now, sending the cached ETag so an unchanged
+// catalog is not downloaded again
+func (c *Catalog) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	return c.refresh(ctx)
+}
+
+// Run refreshes the catalog every RefreshInterval until ctx is done, so
+// lookups rarely wait on the API. Failures are reported to OnError.
+func (c *Catalog) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.Refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stats returns a snapshot of the cache statistics
+func (c *Catalog) Stats() CatalogStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stats := c.stats
+	stats.Size = len(c.games)
+	stats.ETag = c.etag
+	return stats
+}
+
+// ensure makes sure a usable catalog is loaded, refreshing it if expired
+func (c *Catalog) ensure(ctx context.Context) error {
+	if c.fresh() {
+		c.count(&c.stats.Hits)
+		return nil
+	}
+
+	if !c.refreshMu.TryLock() {
+		// Serve what is loaded rather than queue behind a running refresh
+		if c.serveStale() {
+			return nil
+		}
+		c.refreshMu.Lock()
+	}
+	defer c.refreshMu.Unlock()
+	// Another caller may have refreshed while this one waited
+	if c.fresh() {
+		c.count(&c.stats.Hits)
+		return nil
+	}
+	c.count(&c.stats.Misses)
+
+	err := c.refresh(ctx)
+	if err == nil {
+		return nil
+	}
+
+	if !c.serveStale() {
+		return errors.Join(ErrCatalogUnavailable, err)
+	}
+	return nil
+}
+
+// serveStale reports whether expired data may be served, counting it if so
+func (c *Catalog) serveStale() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loaded || (c.maxStale > 0 && c.now().Sub(c.fetchedAt) > c.ttl+c.maxStale) {
+		return false
+	}
+	c.stats.StaleHits++
+	return true
+}
+
+// refresh fetches the catalog; the caller holds refreshMu
+func (c *Catalog) refresh(ctx context.Context) error {
+	c.mu.RLock()
+	etag := c.etag
+	c.mu.RUnlock()
+
+	games, newETag, err := c.source(ctx, etag)
+	now := c.now()
+
+	c.mu.Lock()
+	if err != nil && !(errors.Is(err, ErrNotModified) && c.loaded) {
+		c.stats.RefreshErrors++
+		c.stats.LastError = err
+		c.mu.Unlock()
+		if c.onError != nil {
+			c.onError(err)
+		}
+		return err
+	}
+	if err != nil {
+		c.stats.NotModified++
+	} else {
+		byID := make(map[int]Game, len(games))
+		for _, g := range games {
+			byID[g.ID] = g
+		}
+		c.games, c.byID, c.etag, c.loaded = games, byID, newETag, true
+		c.details = make(map[int]cachedDetails)
+	}
+	c.fetchedAt = now
+	c.stats.Refreshes++
+	c.stats.LastRefresh = now
+	c.stats.LastError = nil
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Catalog) fresh() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.loaded && c.now().Sub(c.fetchedAt) < c.ttl
+}
+
+func (c *Catalog) count(n *int64) {
+	c.mu.Lock()
+	*n++
+	c.mu.Unlock()
+}
+
+// cachedGame returns the cached details of a game while they are fresh
+func (c *Catalog) cachedGame(gameID int) (*GameDetails, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.details[gameID]
+	if !ok || c.now().Sub(d.fetchedAt) >= c.ttl {
+		c.stats.DetailMisses++
+		return nil, false
+	}
+	c.stats.DetailHits++
+	return d.game, true
+}
+
+// storeGame caches the details of a game
+func (c *Catalog) storeGame(game *GameDetails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.details[game.ID] = cachedDetails{game: game, fetchedAt: c.now()}
+}
+
+// lists reports whether the catalog lists a game; warm is false when the
+// catalog is filtered, not loaded or expired, and listed is then meaningless
+func (c *Catalog) lists(gameID int) (listed, warm bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.complete || !c.loaded || c.now().Sub(c.fetchedAt) >= c.ttl {
+		return false, false
+	}
+	_, listed = c.byID[gameID]
+	return listed, true
+}
@@ -1,9 +1,16 @@
 package flows
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+
+	apiclient "github.com/iplaygamesai/api-client-go"
+	"github.com/iplaygamesai/sdk-wrapper-go/responsible"
 )
 
 // ApiResponse represents a generic API response
@@ -42,3 +49,57 @@ func parseResponseBody(resp *http.Response) (map[string]interface{}, error) {
 
 	return result, nil
 }
+
+// apiGet sends a GET request with the client's configuration, for calls
+// the generated client does not cover or that need response headers.
+// operation names the generated operation whose server to use when the
+// configured host has no scheme.
+func apiGet(ctx context.Context, api *apiclient.APIClient, operation, path string, query url.Values, header http.Header) (*http.Response, error) {
+	cfg := api.GetConfig()
+	base := cfg.Host
+	if !strings.Contains(base, "://") {
+		serverURL, err := cfg.ServerURLWithContext(ctx, operation)
+		if err != nil {
+			return nil, err
+		}
+		base = serverURL
+	}
+
+	target := strings.TrimRight(base, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cfg.DefaultHeader {
+		req.Header.Set(k, v)
+	}
+	if cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", cfg.UserAgent)
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// checkGuard asks the guard, if any, whether a session may start. It returns
+// the error code to report alongside the error when a policy blocks play.
+func checkGuard(ctx context.Context, guard *responsible.Guard, playerID, currency string) (string, error) {
+	if guard == nil {
+		return "", nil
+	}
+	err := guard.CheckSession(ctx, playerID, currency)
+	if errors.Is(err, responsible.ErrBlocked) {
+		return responsible.ErrorCode, err
+	}
+	return "", err
+}
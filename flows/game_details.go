@@ -0,0 +1,148 @@
+package flows
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidGameDetails is returned when a game details response cannot be
+// decoded
+var ErrInvalidGameDetails = errors.New("invalid game details")
+
+// GameDetails is the full description of a single game
+type GameDetails struct {
+	ID       int          `json:"id"`
+	Title    string       `json:"title"`
+	Slug     string       `json:"slug,omitempty"`
+	Producer string       `json:"producer,omitempty"`
+	Provider ProviderInfo `json:"provider"`
+	Type     string       `json:"type,omitempty"`
+	// RTP is the theoretical return to player as a percentage, e.g. 96.5
+	RTP        float64 `json:"rtp,omitempty"`
+	Volatility string  `json:"volatility,omitempty"`
+	// MinBet and MaxBet are in major units of the player's currency
+	MinBet float64 `json:"min_bet,omitempty"`
+	MaxBet float64 `json:"max_bet,omitempty"`
+	// Currencies the game can be played in; empty means any
+	Currencies []string `json:"currencies,omitempty"`
+	// RestrictedCountries are ISO 3166-1 alpha-2 codes where the game may
+	// not be offered
+	RestrictedCountries []string `json:"restricted_countries,omitempty"`
+	// Devices the game runs on, e.g. "desktop" and "mobile"; empty means any
+	Devices     []string   `json:"devices,omitempty"`
+	Lines       int        `json:"lines,omitempty"`
+	Features    []string   `json:"features,omitempty"`
+	ReleaseDate time.Time  `json:"release_date,omitempty"`
+	ImageURL    string     `json:"image_url,omitempty"`
+	Thumbnails  Thumbnails `json:"thumbnails,omitempty"`
+	HasDemo     bool       `json:"has_demo,omitempty"`
+}
+
+// ProviderInfo describes the provider that supplies a game
+type ProviderInfo struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	Slug string `json:"slug,omitempty"`
+	Logo string `json:"logo,omitempty"`
+}
+
+// Thumbnails holds image URLs by size, e.g. "small", "medium", "large"
+type Thumbnails map[string]string
+
+// SupportsCurrency reports whether the game can be played in currency,
+// ignoring case
+func (g *GameDetails) SupportsCurrency(currency string) bool {
+	if len(g.Currencies) == 0 {
+		return true
+	}
+	for _, c := range g.Currencies {
+		if strings.EqualFold(c, currency) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsDevice reports whether the game runs on the device, ignoring case
+func (g *GameDetails) SupportsDevice(device string) bool {
+	if len(g.Devices) == 0 {
+		return true
+	}
+	for _, d := range g.Devices {
+		if strings.EqualFold(d, device) {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictedIn reports whether the game is restricted in the country,
+// ignoring case
+func (g *GameDetails) RestrictedIn(countryCode string) bool {
+	for _, c := range g.RestrictedCountries {
+		if strings.EqualFold(c, countryCode) {
+			return true
+		}
+	}
+	return false
+}
+
+// GameDetailsResponse represents a single game response
+type GameDetailsResponse struct {
+	Success bool         `json:"success"`
+	Game    *GameDetails `json:"game,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// DecodeGameDetails decodes a game details body, either bare or wrapped in
+// "data". Fields of the wrong type, a malformed release date or a missing
+// id or title are errors wrapping ErrInvalidGameDetails.
+func DecodeGameDetails(body []byte) (*GameDetails, error) {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGameDetails, err)
+	}
+	raw := json.RawMessage(body)
+	if len(envelope.Data) > 0 && !bytes.Equal(envelope.Data, []byte("null")) {
+		raw = envelope.Data
+	}
+
+	type plain GameDetails
+	var g struct {
+		plain
+		ReleaseDate *string `json:"release_date"`
+	}
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGameDetails, err)
+	}
+	details := GameDetails(g.plain)
+
+	if g.ReleaseDate != nil && *g.ReleaseDate != "" {
+		t, err := parseReleaseDate(*g.ReleaseDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: release_date %q is not a date", ErrInvalidGameDetails, *g.ReleaseDate)
+		}
+		details.ReleaseDate = t
+	}
+	if details.ID <= 0 {
+		return nil, fmt.Errorf("%w: missing id", ErrInvalidGameDetails)
+	}
+	if details.Title == "" {
+		return nil, fmt.Errorf("%w: missing title", ErrInvalidGameDetails)
+	}
+	return &details, nil
+}
+
+// parseReleaseDate accepts a plain date or an RFC 3339 timestamp
+func parseReleaseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
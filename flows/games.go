@@ -2,19 +2,42 @@ package flows
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
 	"strconv"
 
 	apiclient "github.com/iplaygamesai/api-client-go"
+	"github.com/iplaygamesai/sdk-wrapper-go/pagination"
 )
 
 // GamesFlow provides high-level operations for games
 type GamesFlow struct {
-	api *apiclient.APIClient
+	api         *apiclient.APIClient
+	catalog     *Catalog
+	concurrency int
 }
 
 // NewGamesFlow creates a new games flow
 func NewGamesFlow(api *apiclient.APIClient) *GamesFlow {
-	return &GamesFlow{api: api}
+	return &GamesFlow{api: api, concurrency: 8}
+}
+
+// SetCatalog lets GetMany answer from the catalog cache while it is warm
+func (f *GamesFlow) SetCatalog(catalog *Catalog) {
+	f.catalog = catalog
+}
+
+// SetConcurrency limits how many Get calls GetMany runs at once; defaults
+// to 8
+func (f *GamesFlow) SetConcurrency(n int) {
+	if n > 0 {
+		f.concurrency = n
+	}
 }
 
 // ListParams contains parameters for listing games
@@ -24,6 +47,10 @@ type ListParams struct {
 	Provider   string
 	Type       string
 	PerPage    int
+	// Page is the 1-based page number; ignored when Cursor is set
+	Page int
+	// Cursor continues from PaginationMeta.NextCursor of a previous page
+	Cursor string
 }
 
 // Game represents a game
@@ -46,66 +73,191 @@ type GamesListResponse struct {
 
 // PaginationMeta contains pagination information
 type PaginationMeta struct {
-	Total int `json:"total"`
+	Total       int    `json:"total"`
+	PerPage     int    `json:"per_page,omitempty"`
+	CurrentPage int    `json:"current_page,omitempty"`
+	LastPage    int    `json:"last_page,omitempty"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+}
+
+// HasMore reports whether another page follows this one
+func (m PaginationMeta) HasMore() bool {
+	return m.NextCursor != "" || m.CurrentPage < m.LastPage
+}
+
+// next returns the request for the following page, or nil on the last one
+func (m PaginationMeta) next() *pagination.Request {
+	switch {
+	case m.NextCursor != "":
+		return &pagination.Request{Cursor: m.NextCursor}
+	case m.CurrentPage > 0 && m.CurrentPage < m.LastPage:
+		return &pagination.Request{Page: m.CurrentPage + 1}
+	}
+	return nil
 }
 
-// List lists available games
+// List lists available games. Every page goes through the same raw GET,
+// as the generated request has no page or cursor parameter.
 func (f *GamesFlow) List(ctx context.Context, params ListParams) GamesListResponse {
-	req := f.api.GamesAPI.ListGames(ctx)
+	resp, _, err := f.listPage(ctx, params, "")
+	if err != nil {
+		return GamesListResponse{
+			Success: false,
+			Error:   err.Error(),
+			Games:   []Game{},
+			Meta:    PaginationMeta{Total: 0},
+		}
+	}
+	return resp
+}
 
+// All iterates over every game matching params, fetching pages as the loop
+// advances and loading the next page while the current one is consumed.
+// Iteration starts at params.Page or params.Cursor when set. A failed page
+// is yielded as an error and ends the loop.
+//
+//	for game, err := range client.Games().All(ctx, flows.ListParams{PerPage: 100}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(game.Title)
+//	}
+func (f *GamesFlow) All(ctx context.Context, params ListParams) iter.Seq2[Game, error] {
+	first := pagination.Request{Page: params.Page, Cursor: params.Cursor}
+	return pagination.All(ctx, first, f.fetchPage(params))
+}
+
+// fetchPage returns a pagination.Fetch listing the pages of params
+func (f *GamesFlow) fetchPage(params ListParams) pagination.Fetch[Game] {
+	return func(ctx context.Context, req pagination.Request) (pagination.Page[Game], error) {
+		p := params
+		p.Page, p.Cursor = req.Page, req.Cursor
+		resp := f.List(ctx, p)
+		if !resp.Success {
+			return pagination.Page[Game]{}, errors.New(resp.Error)
+		}
+		return pagination.Page[Game]{Items: resp.Games, Next: resp.Meta.next()}, nil
+	}
+}
+
+// catalogSource lists every game matching params. A 304 on page 1 says
+// nothing about later pages, so the ETag is only kept, and sent as
+// If-None-Match on the next load, when the catalog fits on one page.
+func (f *GamesFlow) catalogSource(params ListParams) CatalogSource {
+	return func(ctx context.Context, etag string) ([]Game, string, error) {
+		p := params
+		p.Page, p.Cursor = 1, ""
+		first, newETag, err := f.listPage(ctx, p, etag)
+		if err != nil {
+			return nil, "", err
+		}
+
+		games := first.Games
+		if next := first.Meta.next(); next != nil && len(first.Games) > 0 {
+			newETag = ""
+			for g, err := range pagination.All(ctx, *next, f.fetchPage(params)) {
+				if err != nil {
+					return nil, "", err
+				}
+				games = append(games, g)
+			}
+		}
+		return games, newETag, nil
+	}
+}
+
+// listPage fetches the page of params with a raw GET, conditionally on etag
+// when set, returning ErrNotModified when the server answers 304
+func (f *GamesFlow) listPage(ctx context.Context, params ListParams, etag string) (GamesListResponse, string, error) {
+	query := url.Values{}
 	if params.Search != "" {
-		req = req.Search(params.Search)
+		query.Set("search", params.Search)
 	}
 	if params.ProducerID > 0 {
-		req = req.ProducerId(int32(params.ProducerID))
+		query.Set("producer_id", strconv.Itoa(params.ProducerID))
 	}
 	if params.Provider != "" {
-		req = req.Provider(params.Provider)
+		query.Set("provider", params.Provider)
 	}
 	if params.Type != "" {
-		req = req.Type_(params.Type)
+		query.Set("type", params.Type)
 	}
 	if params.PerPage > 0 {
-		req = req.PerPage(strconv.Itoa(params.PerPage))
+		query.Set("per_page", strconv.Itoa(params.PerPage))
+	}
+	if params.Cursor != "" {
+		query.Set("cursor", params.Cursor)
+	} else {
+		query.Set("page", strconv.Itoa(max(params.Page, 1)))
 	}
 
-	resp, _, err := req.Execute()
+	header := http.Header{}
+	if etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	resp, err := apiGet(ctx, f.api, "GamesAPIService.ListGames", "/api/v1/games", query, header)
 	if err != nil {
-		return GamesListResponse{
-			Success: false,
-			Error:   err.Error(),
-			Games:   []Game{},
-			Meta:    PaginationMeta{Total: 0},
-		}
+		return GamesListResponse{}, "", err
 	}
+	defer resp.Body.Close()
 
-	games := make([]Game, 0)
-	if resp.Data != nil {
-		for _, g := range resp.Data {
-			games = append(games, Game{
-				ID:       int(g.GetId()),
-				Title:    g.GetTitle(),
-				Producer: g.GetProducer(),
-				Type:     g.GetType(),
-				ImageURL: g.GetImageUrl(),
-				HasDemo:  g.GetHasDemo(),
-			})
-		}
+	if resp.StatusCode == http.StatusNotModified {
+		return GamesListResponse{}, etag, ErrNotModified
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return GamesListResponse{}, "", err
+	}
+	if resp.StatusCode >= 300 {
+		return GamesListResponse{}, "", fmt.Errorf("list games: %s", resp.Status)
 	}
 
-	meta := PaginationMeta{Total: len(games)}
-	if resp.Meta != nil {
-		meta.Total = int(resp.Meta.GetTotal())
+	var page struct {
+		Data []Game `json:"data"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return GamesListResponse{}, "", err
 	}
+	meta := PaginationMeta{Total: len(page.Data)}
+	parsePaginationMeta(body, &meta)
+	if page.Data == nil {
+		page.Data = []Game{}
+	}
+	return GamesListResponse{Success: true, Games: page.Data, Meta: meta}, resp.Header.Get("ETag"), nil
+}
 
-	return GamesListResponse{
-		Success: true,
-		Games:   games,
-		Meta:    meta,
+// parsePaginationMeta fills meta from a raw list body. A next_cursor is
+// read from the "meta" block or the top level.
+func parsePaginationMeta(body []byte, meta *PaginationMeta) {
+	var raw struct {
+		Meta struct {
+			Total       *int        `json:"total"`
+			PerPage     json.Number `json:"per_page"`
+			CurrentPage int         `json:"current_page"`
+			LastPage    int         `json:"last_page"`
+			NextCursor  string      `json:"next_cursor"`
+		} `json:"meta"`
+		NextCursor string `json:"next_cursor"`
+	}
+	if json.Unmarshal(body, &raw) != nil {
+		return
+	}
+	if raw.Meta.Total != nil {
+		meta.Total = *raw.Meta.Total
+	}
+	if n, err := raw.Meta.PerPage.Int64(); err == nil {
+		meta.PerPage = int(n)
+	}
+	meta.CurrentPage = raw.Meta.CurrentPage
+	meta.LastPage = raw.Meta.LastPage
+	meta.NextCursor = raw.Meta.NextCursor
+	if meta.NextCursor == "" {
+		meta.NextCursor = raw.NextCursor
 	}
 }
 
-// Get retrieves a single game by ID
+// Get retrieves a single game by ID as raw response data. Use GetDetails for
+// the typed game.
 func (f *GamesFlow) Get(ctx context.Context, gameID int) ApiResponse {
 	httpResp, err := f.api.GamesAPI.GetApiV1GamesId(ctx, strconv.Itoa(gameID)).Execute()
 	if err != nil {
@@ -130,6 +282,50 @@ func (f *GamesFlow) Get(ctx context.Context, gameID int) ApiResponse {
 	}
 }
 
+// GetDetails retrieves a single game by ID decoded into GameDetails. A body
+// that cannot be decoded is reported as a failure, and an unknown ID as
+// ErrGameNotFound.
+func (f *GamesFlow) GetDetails(ctx context.Context, gameID int) GameDetailsResponse {
+	game, err := f.get(ctx, gameID)
+	if err != nil {
+		return GameDetailsResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
+	return GameDetailsResponse{
+		Success: true,
+		Game:    game,
+	}
+}
+
+// get fetches a game's details, returning ErrGameNotFound for an unknown ID
+func (f *GamesFlow) get(ctx context.Context, gameID int) (*GameDetails, error) {
+	httpResp, err := f.api.GamesAPI.GetApiV1GamesId(ctx, strconv.Itoa(gameID)).Execute()
+	if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+		return nil, ErrGameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return readGameDetails(httpResp)
+}
+
+// readGameDetails decodes the game details from the response body
+func readGameDetails(resp *http.Response) (*GameDetails, error) {
+	if resp == nil || resp.Body == nil {
+		return nil, fmt.Errorf("%w: empty response", ErrInvalidGameDetails)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeGameDetails(body)
+}
+
 // ByProducer gets games by producer
 func (f *GamesFlow) ByProducer(ctx context.Context, producerID int, params ListParams) GamesListResponse {
 	params.ProducerID = producerID
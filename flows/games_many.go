@@ -0,0 +1,102 @@
+package flows
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrGameNotFound is reported by GetMany for IDs the catalog does not list
+var ErrGameNotFound = errors.New("game not found")
+
+// GetManyResponse represents a bulk game lookup
+type GetManyResponse struct {
+	// Success is true when every ID was found
+	Success bool                 `json:"success"`
+	Games   map[int]*GameDetails `json:"games"`
+	// Errors holds the reason each missing ID could not be fetched
+	Errors map[int]string `json:"errors,omitempty"`
+}
+
+// GetMany retrieves several games at once. Duplicate IDs are fetched once
+// and at most SetConcurrency lookups run at a time. With SetCatalog, fresh
+// cached details are reused, fetched ones are cached, and IDs a warm
+// catalog does not list fail with ErrGameNotFound without an API call.
+func (f *GamesFlow) GetMany(ctx context.Context, ids []int) GetManyResponse {
+	games, errs := f.getMany(ctx, ids, f.get)
+	resp := GetManyResponse{Success: len(errs) == 0, Games: games}
+	if len(errs) > 0 {
+		resp.Errors = make(map[int]string, len(errs))
+		for id, err := range errs {
+			resp.Errors[id] = err.Error()
+		}
+	}
+	return resp
+}
+
+// getMany looks up ids through get, returning the games found and the error
+// of each ID that was not
+func (f *GamesFlow) getMany(ctx context.Context, ids []int, get func(context.Context, int) (*GameDetails, error)) (map[int]*GameDetails, map[int]error) {
+	games := make(map[int]*GameDetails)
+	errs := make(map[int]error)
+
+	seen := make(map[int]bool, len(ids))
+	var pending []int
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if f.catalog != nil {
+			if game, ok := f.catalog.cachedGame(id); ok {
+				games[id] = game
+				continue
+			}
+			if listed, warm := f.catalog.lists(id); warm && !listed {
+				errs[id] = ErrGameNotFound
+				continue
+			}
+		}
+		pending = append(pending, id)
+	}
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < min(max(f.concurrency, 1), len(pending)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				game, err := get(ctx, id)
+				mu.Lock()
+				if err == nil {
+					games[id] = game
+				} else {
+					errs[id] = err
+				}
+				mu.Unlock()
+				if err == nil && f.catalog != nil {
+					f.catalog.storeGame(game)
+				}
+			}
+		}()
+	}
+
+feed:
+	for i, id := range pending {
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+			mu.Lock()
+			for _, id := range pending[i:] {
+				errs[id] = ctx.Err()
+			}
+			mu.Unlock()
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return games, errs
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 
 	apiclient "github.com/iplaygamesai/api-client-go"
+	"github.com/iplaygamesai/sdk-wrapper-go/responsible"
 )
 
 // MultiSessionFlow provides high-level operations for multi-sessions
 type MultiSessionFlow struct {
-	api *apiclient.APIClient
+	api   *apiclient.APIClient
+	guard *responsible.Guard
 }
 
 // NewMultiSessionFlow creates a new multi-session flow
@@ -17,6 +19,11 @@ func NewMultiSessionFlow(api *apiclient.APIClient) *MultiSessionFlow {
 	return &MultiSessionFlow{api: api}
 }
 
+// SetGuard checks responsible-gambling policies before Start launches games
+func (f *MultiSessionFlow) SetGuard(guard *responsible.Guard) {
+	f.guard = guard
+}
+
 // StartMultiSessionParams contains parameters for starting a multi-session
 type StartMultiSessionParams struct {
 	PlayerID    string
@@ -44,11 +51,21 @@ type MultiSessionResponse struct {
 	Games          []MultiSessionGame `json:"games"`
 	ExpiresAt      string             `json:"expires_at,omitempty"`
 	Error          string             `json:"error,omitempty"`
+	ErrorCode      string             `json:"error_code,omitempty"`
 	Raw            interface{}        `json:"raw,omitempty"`
 }
 
 // Start starts a multi-session for a player
 func (f *MultiSessionFlow) Start(ctx context.Context, params StartMultiSessionParams) MultiSessionResponse {
+	if code, err := checkGuard(ctx, f.guard, params.PlayerID, params.Currency); err != nil {
+		return MultiSessionResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: code,
+			Games:     []MultiSessionGame{},
+		}
+	}
+
 	req := apiclient.NewStartAMultiSessionRequest(params.PlayerID, params.Currency, params.CountryCode, params.IPAddress)
 
 	if len(params.GameIDs) > 0 {
@@ -84,6 +101,10 @@ func (f *MultiSessionFlow) Start(ctx context.Context, params StartMultiSessionPa
 		}
 	}
 
+	if f.guard != nil {
+		f.guard.RecordSessionStart(ctx, params.PlayerID)
+	}
+
 	return MultiSessionResponse{
 		Success:        true,
 		MultiSessionID: resp.Data.GetMultiSessionId(),
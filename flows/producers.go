@@ -0,0 +1,217 @@
+package flows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiclient "github.com/iplaygamesai/api-client-go"
+)
+
+// ProducersFlow lists the game producers (studios) and the providers that
+// supply them
+type ProducersFlow struct {
+	api *apiclient.APIClient
+}
+
+// NewProducersFlow creates a new producers flow
+func NewProducersFlow(api *apiclient.APIClient) *ProducersFlow {
+	return &ProducersFlow{api: api}
+}
+
+// Producer is a game studio
+type Producer struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug,omitempty"`
+	Logo string `json:"logo,omitempty"`
+	// Provider is the aggregator or integration the producer is supplied by
+	Provider string `json:"provider,omitempty"`
+	// GamesCount is as reported by the API, or set by CountGames
+	GamesCount int `json:"games_count"`
+}
+
+// ProducersResponse represents a producers list response
+type ProducersResponse struct {
+	Success   bool       `json:"success"`
+	Producers []Producer `json:"producers"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// List lists the producers available to the operator
+func (f *ProducersFlow) List(ctx context.Context) ProducersResponse {
+	httpResp, err := f.api.EndpointsAPI.GetApiV1Producers(ctx).Execute()
+	if err != nil {
+		return ProducersResponse{Success: false, Error: err.Error(), Producers: []Producer{}}
+	}
+
+	var page struct {
+		Data []Producer `json:"data"`
+	}
+	if err := decodeBody(httpResp, &page); err != nil {
+		return ProducersResponse{Success: false, Error: fmt.Sprintf("list producers: %v", err), Producers: []Producer{}}
+	}
+	if page.Data == nil {
+		page.Data = []Producer{}
+	}
+	return ProducersResponse{Success: true, Producers: page.Data}
+}
+
+// Get finds a producer by ID. An unknown ID is reported as not found
+// rather than as an error.
+func (f *ProducersFlow) Get(ctx context.Context, producerID int) (Producer, bool, error) {
+	httpResp, err := f.api.EndpointsAPI.GetApiV1ProducersId(ctx, strconv.Itoa(producerID)).Execute()
+	if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+		return Producer{}, false, nil
+	}
+	if err != nil {
+		return Producer{}, false, err
+	}
+
+	// The producer is either bare or wrapped in "data"
+	var body struct {
+		Producer
+		Data *Producer `json:"data"`
+	}
+	if err := decodeBody(httpResp, &body); err != nil {
+		return Producer{}, false, fmt.Errorf("get producer %d: %w", producerID, err)
+	}
+	if body.Data != nil {
+		return *body.Data, true, nil
+	}
+	return body.Producer, true, nil
+}
+
+// decodeBody decodes a JSON response body into v and closes it
+func decodeBody(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// Producers lists the producers; see ProducersFlow.List
+func (f *GamesFlow) Producers(ctx context.Context) ProducersResponse {
+	return NewProducersFlow(f.api).List(ctx)
+}
+
+// CountGames returns producers with GamesCount set from games, matching
+// Game.Producer to the producer's name or slug, ignoring case
+func CountGames(producers []Producer, games []Game) []Producer {
+	counts := make(map[string]int)
+	for _, g := range games {
+		counts[strings.ToLower(g.Producer)]++
+	}
+	counted := make([]Producer, len(producers))
+	for i, p := range producers {
+		p.GamesCount = counts[strings.ToLower(p.Name)]
+		if p.Slug != "" && !strings.EqualFold(p.Slug, p.Name) {
+			p.GamesCount += counts[strings.ToLower(p.Slug)]
+		}
+		counted[i] = p
+	}
+	return counted
+}
+
+// Provider groups the producers supplied through one provider
+type Provider struct {
+	Name       string     `json:"name"`
+	Producers  []Producer `json:"producers"`
+	GamesCount int        `json:"games_count"`
+}
+
+// GroupByProvider groups producers by Provider, both sorted by name
+func GroupByProvider(producers []Producer) []Provider {
+	index := make(map[string]int)
+	var providers []Provider
+	for _, p := range producers {
+		i, ok := index[p.Provider]
+		if !ok {
+			i = len(providers)
+			index[p.Provider] = i
+			providers = append(providers, Provider{Name: p.Provider})
+		}
+		providers[i].Producers = append(providers[i].Producers, p)
+		providers[i].GamesCount += p.GamesCount
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	for _, prov := range providers {
+		sort.Slice(prov.Producers, func(i, j int) bool { return prov.Producers[i].Name < prov.Producers[j].Name })
+	}
+	return providers
+}
+
+// NavOptions configures ProducerNav
+type NavOptions struct {
+	// Featured producers, by name or slug, come first in this order
+	Featured []string
+	// MinGames hides producers with fewer games; defaults to 1, so producers
+	// without games are hidden. Negative shows every producer.
+	MinGames int
+	// Limit caps the number of entries; 0 means no limit
+	Limit int
+}
+
+// NavItem is one entry of a lobby's producer navigation
+type NavItem struct {
+	Producer Producer `json:"producer"`
+	Featured bool     `json:"featured,omitempty"`
+	// Params lists the producer's games with GamesFlow.List
+	Params ListParams `json:"-"`
+}
+
+// ProducerNav orders producers for a lobby menu: featured producers first,
+// then the rest by game count and name. Set GamesCount first, from the API
+// or with CountGames.
+func ProducerNav(producers []Producer, opts NavOptions) []NavItem {
+	if opts.MinGames == 0 {
+		opts.MinGames = 1
+	}
+	featured := make(map[string]int, len(opts.Featured))
+	for i, name := range opts.Featured {
+		featured[strings.ToLower(name)] = i + 1
+	}
+	rank := func(p Producer) int {
+		if r := featured[strings.ToLower(p.Name)]; r > 0 {
+			return r
+		}
+		return featured[strings.ToLower(p.Slug)]
+	}
+
+	items := make([]NavItem, 0, len(producers))
+	for _, p := range producers {
+		if p.GamesCount < opts.MinGames {
+			continue
+		}
+		items = append(items, NavItem{
+			Producer: p,
+			Featured: rank(p) > 0,
+			Params:   ListParams{ProducerID: p.ID},
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].Producer, items[j].Producer
+		ra, rb := rank(a), rank(b)
+		switch {
+		case ra > 0 && rb > 0:
+			return ra < rb
+		case ra > 0 || rb > 0:
+			return ra > 0
+		case a.GamesCount != b.GamesCount:
+			return a.GamesCount > b.GamesCount
+		}
+		return a.Name < b.Name
+	})
+	if opts.Limit > 0 && len(items) > opts.Limit {
+		items = items[:opts.Limit]
+	}
+	return items
+}
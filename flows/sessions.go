@@ -2,15 +2,21 @@ package flows
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	apiclient "github.com/iplaygamesai/api-client-go"
+	"github.com/iplaygamesai/sdk-wrapper-go/freespins"
+	"github.com/iplaygamesai/sdk-wrapper-go/responsible"
 )
 
 // SessionsFlow provides high-level operations for sessions
 type SessionsFlow struct {
-	api *apiclient.APIClient
+	api       *apiclient.APIClient
+	freespins *freespins.Tracker
+	guard     *responsible.Guard
+	games     *GamesFlow
 }
 
 // NewSessionsFlow creates a new sessions flow
@@ -18,6 +24,23 @@ func NewSessionsFlow(api *apiclient.APIClient) *SessionsFlow {
 	return &SessionsFlow{api: api}
 }
 
+// SetFreespinTracker records freespin grants made through Start in the tracker
+func (f *SessionsFlow) SetFreespinTracker(tracker *freespins.Tracker) {
+	f.freespins = tracker
+}
+
+// SetGuard checks responsible-gambling policies before Start launches a game
+func (f *SessionsFlow) SetGuard(guard *responsible.Guard) {
+	f.guard = guard
+}
+
+// SetPreflight makes Start check the game's metadata from games before
+// launching it, refusing unsupported currencies, restricted countries and
+// devices, and StartDemo games without a demo. Pass nil to turn it off.
+func (f *SessionsFlow) SetPreflight(games *GamesFlow) {
+	f.games = games
+}
+
 // StartSessionParams contains parameters for starting a session
 type StartSessionParams struct {
 	GameID           int
@@ -42,11 +65,38 @@ type SessionResponse struct {
 	GameURL   string      `json:"game_url"`
 	ExpiresAt string      `json:"expires_at,omitempty"`
 	Error     string      `json:"error,omitempty"`
+	ErrorCode string      `json:"error_code,omitempty"`
 	Raw       interface{} `json:"raw,omitempty"`
+	// Reason is set when the preflight check refused the launch
+	Reason UnavailableReason `json:"reason,omitempty"`
 }
 
-// Start starts a new game session
+// Start starts a new game session. With SetPreflight, a launch the game's
+// metadata rules out fails with ErrorCode GAME_UNAVAILABLE and a Reason.
 func (f *SessionsFlow) Start(ctx context.Context, params StartSessionParams) SessionResponse {
+	return f.start(ctx, params, params, false)
+}
+
+// start launches the session. Preflight checks the currency and country of
+// checked, which leaves out the defaults StartDemo fills in.
+func (f *SessionsFlow) start(ctx context.Context, params, checked StartSessionParams, demo bool) SessionResponse {
+	var unavailable *UnavailableError
+	if errors.As(f.preflight(ctx, params.GameID, checked.Currency, checked.CountryCode, params.Device, demo), &unavailable) {
+		return SessionResponse{
+			Success:   false,
+			Error:     unavailable.Error(),
+			ErrorCode: ErrorCodeGameUnavailable,
+			Reason:    unavailable.Reason,
+		}
+	}
+	if code, err := checkGuard(ctx, f.guard, params.PlayerID, params.Currency); err != nil {
+		return SessionResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: code,
+		}
+	}
+
 	req := apiclient.NewStartAGameSessionRequest(int32(params.GameID), params.PlayerID, params.Currency, params.IPAddress, params.CountryCode)
 
 	if params.ReturnURL != "" {
@@ -82,6 +132,24 @@ func (f *SessionsFlow) Start(ctx context.Context, params StartSessionParams) Ses
 		}
 	}
 
+	if f.freespins != nil && params.FreespinID != "" && params.FreespinCount > 0 {
+		grant := freespins.Grant{
+			ID:        params.FreespinID,
+			PlayerID:  params.PlayerID,
+			GameID:    params.GameID,
+			Currency:  params.Currency,
+			Count:     params.FreespinCount,
+			BetAmount: params.FreespinBetAmount,
+		}
+		if params.ExpireDays > 0 {
+			grant.ExpiresAt = time.Now().AddDate(0, 0, params.ExpireDays)
+		}
+		f.freespins.RecordGrant(grant)
+	}
+	if f.guard != nil {
+		f.guard.RecordSessionStart(ctx, params.PlayerID)
+	}
+
 	return SessionResponse{
 		Success:   true,
 		SessionID: resp.Data.GetSessionId(),
@@ -134,8 +202,12 @@ func (f *SessionsFlow) End(ctx context.Context, sessionID string) ApiResponse {
 	}
 }
 
-// StartDemo starts a demo session
+// StartDemo starts a demo session. With SetPreflight, games without a demo
+// are refused with ReasonDemoNotSupported; the currency and country are
+// only checked when the caller sets them, not against the USD and US
+// defaults.
 func (f *SessionsFlow) StartDemo(ctx context.Context, gameID int, params StartSessionParams) SessionResponse {
+	checked := params
 	if params.PlayerID == "" {
 		params.PlayerID = fmt.Sprintf("demo_%d", time.Now().UnixMilli())
 	}
@@ -150,5 +222,5 @@ func (f *SessionsFlow) StartDemo(ctx context.Context, gameID int, params StartSe
 	}
 	params.GameID = gameID
 
-	return f.Start(ctx, params)
+	return f.start(ctx, params, checked, true)
 }
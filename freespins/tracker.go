@@ -0,0 +1,370 @@
+// Package freespins follows freespin campaigns from grant to completion
+package freespins
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
+)
+
+// ErrNoFreespinID is returned when a grant has no campaign ID
+var ErrNoFreespinID = errors.New("freespin grant has no freespin ID")
+
+// Campaign status constants
+const (
+	StatusGranted   = "granted"
+	StatusActive    = "active"
+	StatusCompleted = "completed"
+	StatusExpired   = "expired"
+)
+
+// Grant describes freespins awarded when starting a session
+type Grant struct {
+	ID        string
+	PlayerID  string
+	GameID    int
+	Currency  string
+	Count     int
+	BetAmount float64
+	ExpiresAt time.Time // zero means no expiry
+}
+
+// Campaign is the tracked state of one freespin campaign
+type Campaign struct {
+	ID        string
+	PlayerID  string
+	GameID    int
+	Currency  string
+	BetAmount float64
+	Status    string
+
+	Total     int
+	Played    int
+	Remaining int
+
+	// Winnings is the sum of freespin wins in minor units; a redelivered
+	// win is counted once
+	Winnings int64
+	// ReportedWinnings is the provider's own running total, when sent
+	ReportedWinnings *float64
+
+	GrantedAt  time.Time
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// IsFinished reports whether the campaign has completed or expired
+func (c Campaign) IsFinished() bool {
+	return c.Status == StatusCompleted || c.Status == StatusExpired
+}
+
+// Options configures a Tracker
+type Options struct {
+	// OnFinished is called once when a campaign completes or expires
+	OnFinished func(Campaign)
+	// SettleGrace is how long a campaign with no spins remaining waits for
+	// a final win before Check completes it; defaults to 1 minute
+	SettleGrace time.Duration
+	// Retention is how long Check keeps finished campaigns before removing
+	// them; defaults to 24 hours, negative keeps them until Forget or Prune
+	Retention time.Duration
+	// Now overrides the clock, mainly for tests
+	Now func() time.Time
+}
+
+type campaign struct {
+	Campaign
+	rounds map[string]bool
+	wins   map[int64]bool
+}
+
+// Tracker follows freespin campaigns keyed by freespin ID. It is safe for
+// concurrent use.
+type Tracker struct {
+	mu         sync.Mutex
+	campaigns  map[string]*campaign
+	onFinished func(Campaign)
+	grace      time.Duration
+	retention  time.Duration
+	now        func() time.Time
+}
+
+// NewTracker creates a new freespin tracker
+func NewTracker(opts Options) *Tracker {
+	if opts.SettleGrace <= 0 {
+		opts.SettleGrace = time.Minute
+	}
+	if opts.Retention == 0 {
+		opts.Retention = 24 * time.Hour
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Tracker{
+		campaigns:  make(map[string]*campaign),
+		onFinished: opts.OnFinished,
+		grace:      opts.SettleGrace,
+		retention:  opts.Retention,
+		now:        opts.Now,
+	}
+}
+
+// RecordGrant registers freespins awarded to a player. Granting an ID that
+// is already tracked updates its details but keeps progress.
+func (t *Tracker) RecordGrant(g Grant) (Campaign, error) {
+	if g.ID == "" {
+		return Campaign{}, ErrNoFreespinID
+	}
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.get(g.ID, now)
+	c.PlayerID = g.PlayerID
+	c.GameID = g.GameID
+	c.Currency = g.Currency
+	c.BetAmount = g.BetAmount
+	c.ExpiresAt = g.ExpiresAt
+	if g.Count > 0 {
+		c.Total = g.Count
+		c.Remaining = g.Count - c.Played
+	}
+	c.GrantedAt = now
+	c.UpdatedAt = now
+	return c.Campaign, nil
+}
+
+// Track consumes a parsed webhook. Only freespin bets and wins are used.
+func (t *Tracker) Track(p *webhooks.Payload) (Campaign, bool) {
+	if !p.IsBet() && !p.IsWin() {
+		return Campaign{}, false
+	}
+	event, err := p.Event()
+	if err != nil {
+		return Campaign{}, false
+	}
+	return t.TrackEvent(event)
+}
+
+// TrackEvent consumes a typed webhook event and returns the updated
+// campaign. It returns false for events that are not part of a campaign.
+func (t *Tracker) TrackEvent(event webhooks.Event) (Campaign, bool) {
+	var (
+		fs      *webhooks.Freespin
+		roundID string
+		txID    int64
+		win     int64
+		isWin   bool
+	)
+	switch e := event.(type) {
+	case *webhooks.BetEvent:
+		fs, roundID = e.Freespin, e.RoundID
+	case *webhooks.WinEvent:
+		fs, roundID, txID, win, isWin = e.Freespin, e.RoundID, e.TransactionID, e.Amount, true
+	default:
+		return Campaign{}, false
+	}
+	if fs == nil || fs.ID == "" {
+		return Campaign{}, false
+	}
+
+	now := t.now()
+	common := event.Common()
+
+	t.mu.Lock()
+	c := t.get(fs.ID, now)
+	if c.PlayerID == "" {
+		c.PlayerID = common.PlayerID
+	}
+	if c.Currency == "" {
+		c.Currency = common.Currency
+	}
+	if c.GameID == 0 && common.GameID != nil {
+		c.GameID = *common.GameID
+	}
+	if c.Status == StatusGranted {
+		c.Status = StatusActive
+		c.StartedAt = now
+	}
+
+	if fs.Total != nil {
+		c.Total = *fs.Total
+	}
+	if roundID != "" && !c.rounds[roundID] {
+		c.rounds[roundID] = true
+		c.Played++
+	}
+	if fs.RoundNumber != nil && *fs.RoundNumber > c.Played {
+		c.Played = *fs.RoundNumber
+	}
+	switch {
+	case fs.Remaining != nil:
+		c.Remaining = *fs.Remaining
+	case c.Total > 0:
+		c.Remaining = max(c.Total-c.Played, 0)
+	}
+	if isWin && !c.wins[txID] {
+		c.wins[txID] = true
+		c.Winnings += win
+	}
+	if fs.TotalWinnings != nil {
+		v := *fs.TotalWinnings
+		c.ReportedWinnings = &v
+	}
+	c.UpdatedAt = now
+
+	// The last spin is settled by its win
+	finished := false
+	if isWin && c.Remaining == 0 && c.Total > 0 && !c.IsFinished() {
+		t.finish(c, StatusCompleted, now)
+		finished = true
+	}
+	snapshot := c.Campaign
+	t.mu.Unlock()
+
+	if finished {
+		t.notify(snapshot)
+	}
+	return snapshot, true
+}
+
+// Campaign returns the campaign with the given freespin ID
+func (t *Tracker) Campaign(id string) (Campaign, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.campaigns[id]
+	if !ok {
+		return Campaign{}, false
+	}
+	return c.Campaign, true
+}
+
+// Active returns campaigns that have not finished
+func (t *Tracker) Active() []Campaign {
+	return t.filter(func(c *campaign) bool { return !c.IsFinished() })
+}
+
+// ByPlayer returns all campaigns for a player
+func (t *Tracker) ByPlayer(playerID string) []Campaign {
+	return t.filter(func(c *campaign) bool { return c.PlayerID == playerID })
+}
+
+// Check expires campaigns past their expiry, completes campaigns with no
+// spins remaining whose final win never arrived, removes campaigns finished
+// longer than Retention ago, and returns the campaigns finished by this call
+func (t *Tracker) Check() []Campaign {
+	now := t.now()
+	if t.retention > 0 {
+		t.Prune(now.Add(-t.retention))
+	}
+
+	t.mu.Lock()
+	var finished []Campaign
+	for _, c := range t.campaigns {
+		if c.IsFinished() {
+			continue
+		}
+		switch {
+		case c.Total > 0 && c.Remaining == 0 && now.Sub(c.UpdatedAt) >= t.grace:
+			t.finish(c, StatusCompleted, now)
+		case !c.ExpiresAt.IsZero() && now.After(c.ExpiresAt):
+			t.finish(c, StatusExpired, now)
+		default:
+			continue
+		}
+		finished = append(finished, c.Campaign)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(finished, func(i, j int) bool { return finished[i].ID < finished[j].ID })
+	for _, c := range finished {
+		t.notify(c)
+	}
+	return finished
+}
+
+// Run calls Check on every interval until the context is cancelled
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Check()
+		}
+	}
+}
+
+// Forget removes a campaign from the tracker
+func (t *Tracker) Forget(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.campaigns, id)
+}
+
+// Prune removes campaigns that finished before the given time and returns
+// how many were removed. Campaigns still running are kept.
+func (t *Tracker) Prune(before time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for id, c := range t.campaigns {
+		if c.IsFinished() && c.FinishedAt.Before(before) {
+			delete(t.campaigns, id)
+			n++
+		}
+	}
+	return n
+}
+
+func (t *Tracker) get(id string, now time.Time) *campaign {
+	c, ok := t.campaigns[id]
+	if !ok {
+		c = &campaign{
+			Campaign: Campaign{ID: id, Status: StatusGranted, GrantedAt: now, UpdatedAt: now},
+			rounds:   make(map[string]bool),
+			wins:     make(map[int64]bool),
+		}
+		t.campaigns[id] = c
+	}
+	return c
+}
+
+func (t *Tracker) finish(c *campaign, status string, now time.Time) {
+	c.Status = status
+	c.FinishedAt = now
+	c.UpdatedAt = now
+}
+
+func (t *Tracker) filter(match func(*campaign) bool) []Campaign {
+	t.mu.Lock()
+	result := make([]Campaign, 0)
+	for _, c := range t.campaigns {
+		if match(c) {
+			result = append(result, c.Campaign)
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+func (t *Tracker) notify(c Campaign) {
+	if t.onFinished != nil {
+		t.onFinished(c)
+	}
+}
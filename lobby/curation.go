@@ -0,0 +1,221 @@
+package lobby
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrMissingIDColumn is returned when a curation CSV has no id column
+var ErrMissingIDColumn = errors.New("curation csv has no id column")
+
+// ImportError reports a bad cell in a curation CSV
+type ImportError struct {
+	Line   int
+	Column string
+	Err    error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("line %d, column %s: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// Category is a hand-made list of games
+type Category struct {
+	Name  string
+	Games []int
+}
+
+// Curation is the editorial configuration a content team keeps in a
+// spreadsheet
+type Curation struct {
+	// Pinned games, ordered by pin_order and then by row
+	Pinned []int
+	Hidden []int
+	// Categories in order of first appearance; games in row order
+	Categories []Category
+}
+
+// ImportCSV reads a curation CSV, such as a catalog export with extra
+// columns filled in. The header names the columns, in any order and case:
+//
+//	id          game ID, required
+//	pinned      true/false, yes/no, 1/0 or x
+//	pin_order   optional number ordering the pinned games
+//	hidden      as pinned
+//	categories  category names separated by ";" or "|"
+//
+// Other columns, like the exported title or producer, are ignored.
+func ImportCSV(r io.Reader) (*Curation, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, ErrMissingIDColumn
+	}
+	if err != nil {
+		return nil, err
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		col[name] = i
+	}
+	if _, ok := col["id"]; !ok {
+		return nil, ErrMissingIDColumn
+	}
+
+	type pin struct {
+		id, order int
+	}
+	var pins []pin
+	c := &Curation{}
+	categories := make(map[string]int)
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		cell := func(name string) string {
+			if i, ok := col[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		fail := func(column string, err error) error {
+			return &ImportError{Line: line, Column: column, Err: err}
+		}
+
+		rawID := cell("id")
+		if rawID == "" {
+			continue
+		}
+		id, err := strconv.Atoi(rawID)
+		if err != nil || id <= 0 {
+			return nil, fail("id", fmt.Errorf("invalid game id %q", rawID))
+		}
+
+		pinned, err := parseFlag(cell("pinned"))
+		if err != nil {
+			return nil, fail("pinned", err)
+		}
+		if pinned {
+			order := 0
+			if raw := cell("pin_order"); raw != "" {
+				if order, err = strconv.Atoi(raw); err != nil {
+					return nil, fail("pin_order", fmt.Errorf("invalid number %q", raw))
+				}
+			}
+			pins = append(pins, pin{id: id, order: order})
+		}
+
+		hidden, err := parseFlag(cell("hidden"))
+		if err != nil {
+			return nil, fail("hidden", err)
+		}
+		if hidden {
+			c.Hidden = append(c.Hidden, id)
+		}
+
+		names := strings.FieldsFunc(cell("categories"), func(r rune) bool { return r == ';' || r == '|' })
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			i, ok := categories[strings.ToLower(name)]
+			if !ok {
+				i = len(c.Categories)
+				categories[strings.ToLower(name)] = i
+				c.Categories = append(c.Categories, Category{Name: name})
+			}
+			c.Categories[i].Games = append(c.Categories[i].Games, id)
+		}
+	}
+
+	// Rows without a pin_order keep their place after the ordered ones
+	sort.SliceStable(pins, func(i, j int) bool {
+		a, b := pins[i], pins[j]
+		if (a.order == 0) != (b.order == 0) {
+			return a.order != 0
+		}
+		return a.order < b.order
+	})
+	for _, p := range pins {
+		c.Pinned = append(c.Pinned, p.id)
+	}
+	return c, nil
+}
+
+// Apply adds the curation to lobby options: pinned and hidden games, and
+// one featured section per category after the existing sections. Section
+// IDs are slugs of the category names, made unique with a numeric suffix
+// ("summer-hits-2"); a name without letters or digits gets "category-N".
+func (c *Curation) Apply(opts Options) Options {
+	opts.Pinned = append(append([]int(nil), opts.Pinned...), c.Pinned...)
+	opts.Hidden = append(append([]int(nil), opts.Hidden...), c.Hidden...)
+	sections := append([]Section(nil), opts.Sections...)
+	taken := make(map[string]bool, len(sections))
+	for _, s := range sections {
+		taken[s.ID] = true
+	}
+	for i, cat := range c.Categories {
+		base := slug(cat.Name)
+		if base == "" {
+			base = "category-" + strconv.Itoa(i+1)
+		}
+		id := base
+		for n := 2; taken[id]; n++ {
+			id = base + "-" + strconv.Itoa(n)
+		}
+		taken[id] = true
+		sections = append(sections, FeaturedSection(id, cat.Name, cat.Games...))
+	}
+	opts.Sections = sections
+	return opts
+}
+
+func parseFlag(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "", "0", "false", "no", "n":
+		return false, nil
+	case "1", "true", "yes", "y", "x":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid flag %q", s)
+}
+
+// slug turns a category name into a section ID, e.g. "Summer Hits!" into
+// "summer-hits"
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,237 @@
+// Package lobby composes game lobbies from the catalog
+package lobby
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+)
+
+// Viewer is the player a lobby is built for
+type Viewer struct {
+	// Country is an ISO 3166-1 alpha-2 code; empty skips country rules
+	Country string
+	// Currency is an ISO 4217 code; empty skips currency rules
+	Currency string
+}
+
+// Entry is a catalog game with the data sections filter and sort on
+type Entry struct {
+	flows.Game
+	// Details, when known, supply the release date and the currency and
+	// country restrictions
+	Details    *flows.GameDetails
+	Popularity float64
+
+	position int
+}
+
+// Released returns the release date, or zero when unknown
+func (e Entry) Released() time.Time {
+	if e.Details == nil {
+		return time.Time{}
+	}
+	return e.Details.ReleaseDate
+}
+
+// Options configures a Lobby
+type Options struct {
+	Sections []Section
+	// Pinned games lead every section whose filter they pass, in this
+	// order, after the section's own pins
+	Pinned []int
+	// Hidden games never appear, pinned or not
+	Hidden []int
+	// Details by game ID, e.g. from GamesFlow.Get
+	Details map[int]*flows.GameDetails
+	// ShowWithoutDetails offers games missing from Details to viewers with
+	// a Country or Currency. By default they are hidden from such viewers,
+	// since their restrictions are unknown.
+	ShowWithoutDetails bool
+	// Popularity scores by game ID, e.g. plays over the last week
+	Popularity map[int]float64
+	// Available adds operator rules on top of the games' own currency and
+	// country restrictions
+	Available func(e Entry, v Viewer) bool
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// Lobby builds the sections of a lobby for a viewer. It is read-only once
+// created and safe for concurrent use.
+type Lobby struct {
+	sections   []Section
+	pinned     []int
+	hidden     map[int]bool
+	details    map[int]*flows.GameDetails
+	popularity map[int]float64
+	available  func(Entry, Viewer) bool
+	showAll    bool
+	now        func() time.Time
+}
+
+// New creates a lobby
+func New(opts Options) *Lobby {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	hidden := make(map[int]bool, len(opts.Hidden))
+	for _, id := range opts.Hidden {
+		hidden[id] = true
+	}
+	return &Lobby{
+		sections:   opts.Sections,
+		pinned:     opts.Pinned,
+		hidden:     hidden,
+		details:    opts.Details,
+		popularity: opts.Popularity,
+		available:  opts.Available,
+		showAll:    opts.ShowWithoutDetails,
+		now:        opts.Now,
+	}
+}
+
+// Model is the lobby as sent to the frontend
+type Model struct {
+	Country     string         `json:"country,omitempty"`
+	Currency    string         `json:"currency,omitempty"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Sections    []SectionModel `json:"sections"`
+}
+
+// SectionModel is one rendered section
+type SectionModel struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Total is the number of games in the section before Limit
+	Total int         `json:"total"`
+	Games []GameModel `json:"games"`
+}
+
+// GameModel is one game tile
+type GameModel struct {
+	ID         int        `json:"id"`
+	Title      string     `json:"title"`
+	Producer   string     `json:"producer"`
+	Type       string     `json:"type"`
+	ImageURL   string     `json:"image_url,omitempty"`
+	HasDemo    bool       `json:"has_demo"`
+	Pinned     bool       `json:"pinned,omitempty"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+}
+
+// Build renders the lobby of games for the viewer. Games that are hidden or
+// not available to the viewer are left out of every section, and empty
+// sections are dropped unless KeepEmpty is set.
+func (l *Lobby) Build(games []flows.Game, v Viewer) Model {
+	now := l.now()
+	v.Country, v.Currency = strings.ToUpper(v.Country), strings.ToUpper(v.Currency)
+	entries := make([]Entry, 0, len(games))
+	byID := make(map[int]Entry, len(games))
+	for i, g := range games {
+		e := Entry{Game: g, Details: l.details[g.ID], Popularity: l.popularity[g.ID], position: i}
+		if l.hidden[g.ID] || !l.allowed(e, v) {
+			continue
+		}
+		entries = append(entries, e)
+		byID[g.ID] = e
+	}
+
+	model := Model{Country: v.Country, Currency: v.Currency, GeneratedAt: now, Sections: []SectionModel{}}
+	for _, s := range l.sections {
+		section := buildSection(s, l.pinned, entries, byID, now)
+		if len(section.Games) == 0 && !s.KeepEmpty {
+			continue
+		}
+		model.Sections = append(model.Sections, section)
+	}
+	return model
+}
+
+// Section renders a single section by ID
+func (l *Lobby) Section(id string, games []flows.Game, v Viewer) (SectionModel, bool) {
+	for _, s := range l.sections {
+		if s.ID == id {
+			sub := *l
+			sub.sections = []Section{s}
+			sub.sections[0].KeepEmpty = true
+			return sub.Build(games, v).Sections[0], true
+		}
+	}
+	return SectionModel{}, false
+}
+
+// allowed applies the game's own restrictions and the operator rules. A
+// game without details fails closed when the viewer has rules to check.
+func (l *Lobby) allowed(e Entry, v Viewer) bool {
+	if d := e.Details; d != nil {
+		if v.Currency != "" && !d.SupportsCurrency(v.Currency) {
+			return false
+		}
+		if v.Country != "" && d.RestrictedIn(v.Country) {
+			return false
+		}
+	} else if (v.Currency != "" || v.Country != "") && !l.showAll {
+		return false
+	}
+	return l.available == nil || l.available(e, v)
+}
+
+func buildSection(s Section, lobbyPins []int, entries []Entry, byID map[int]Entry, now time.Time) SectionModel {
+	pinned := make(map[int]bool, len(s.Pinned))
+	var games []GameModel
+	for _, id := range s.Pinned {
+		if e, ok := byID[id]; ok && !pinned[id] {
+			pinned[id] = true
+			games = append(games, gameModel(e, true))
+		}
+	}
+	for _, id := range lobbyPins {
+		if e, ok := byID[id]; ok && !pinned[id] && (s.Filter == nil || s.Filter(e, now)) {
+			pinned[id] = true
+			games = append(games, gameModel(e, true))
+		}
+	}
+
+	var rest []Entry
+	for _, e := range entries {
+		if !pinned[e.ID] && (s.Filter == nil || s.Filter(e, now)) {
+			rest = append(rest, e)
+		}
+	}
+	order := s.Sort
+	if order == "" {
+		order = ByTitle
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return order.less(rest[i], rest[j]) })
+	for _, e := range rest {
+		games = append(games, gameModel(e, false))
+	}
+
+	total := len(games)
+	if s.Limit > 0 && len(games) > s.Limit {
+		games = games[:s.Limit]
+	}
+	if games == nil {
+		games = []GameModel{}
+	}
+	return SectionModel{ID: s.ID, Title: s.Title, Total: total, Games: games}
+}
+
+func gameModel(e Entry, pinned bool) GameModel {
+	g := GameModel{
+		ID:       e.ID,
+		Title:    e.Title,
+		Producer: e.Producer,
+		Type:     e.Type,
+		ImageURL: e.ImageURL,
+		HasDemo:  e.HasDemo,
+		Pinned:   pinned,
+	}
+	if released := e.Released(); !released.IsZero() {
+		g.ReleasedAt = &released
+	}
+	return g
+}
@@ -0,0 +1,146 @@
+package lobby
+
+import (
+	"strings"
+	"time"
+)
+
+// Filter selects the games of a section
+type Filter func(e Entry, now time.Time) bool
+
+// ReleasedWithin keeps games released in the last d; games without a known
+// release date are left out
+func ReleasedWithin(d time.Duration) Filter {
+	return func(e Entry, now time.Time) bool {
+		released := e.Released()
+		return !released.IsZero() && !released.After(now) && now.Sub(released) <= d
+	}
+}
+
+// Popular keeps games with a popularity score
+func Popular() Filter {
+	return func(e Entry, now time.Time) bool { return e.Popularity > 0 }
+}
+
+// ByProducer keeps games from the producers, ignoring case
+func ByProducer(producers ...string) Filter {
+	return func(e Entry, now time.Time) bool { return containsFold(producers, e.Producer) }
+}
+
+// ByType keeps games of the types, ignoring case
+func ByType(types ...string) Filter {
+	return func(e Entry, now time.Time) bool { return containsFold(types, e.Type) }
+}
+
+// WithDemo keeps games that can be played in demo mode
+func WithDemo() Filter {
+	return func(e Entry, now time.Time) bool { return e.HasDemo }
+}
+
+// IDs keeps the listed games
+func IDs(ids ...int) Filter {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return func(e Entry, now time.Time) bool { return set[e.ID] }
+}
+
+// And keeps games passing every filter
+func And(filters ...Filter) Filter {
+	return func(e Entry, now time.Time) bool {
+		for _, f := range filters {
+			if !f(e, now) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// SortOrder orders the games of a section
+type SortOrder string
+
+const (
+	// ByTitle sorts alphabetically; the default
+	ByTitle SortOrder = "title"
+	// Newest sorts by release date, newest first
+	Newest SortOrder = "newest"
+	// MostPopular sorts by popularity, highest first
+	MostPopular SortOrder = "popular"
+	// ProducerThenTitle groups games by producer
+	ProducerThenTitle SortOrder = "producer"
+	// CatalogOrder keeps the order games were given in
+	CatalogOrder SortOrder = "catalog"
+)
+
+// less reports whether a sorts before b; ties fall back to the title
+func (o SortOrder) less(a, b Entry) bool {
+	switch o {
+	case Newest:
+		if ra, rb := a.Released(), b.Released(); !ra.Equal(rb) {
+			return ra.After(rb)
+		}
+	case MostPopular:
+		if a.Popularity != b.Popularity {
+			return a.Popularity > b.Popularity
+		}
+	case ProducerThenTitle:
+		if pa, pb := strings.ToLower(a.Producer), strings.ToLower(b.Producer); pa != pb {
+			return pa < pb
+		}
+	case CatalogOrder:
+		return a.position < b.position
+	}
+	return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+}
+
+// Section is a named row of the lobby
+type Section struct {
+	ID    string
+	Title string
+	// Filter defaults to every game
+	Filter Filter
+	Sort   SortOrder
+	// Pinned games lead the section in this order, whether or not they pass
+	// the filter
+	Pinned []int
+	// Limit caps the games shown; 0 shows all
+	Limit int
+	// KeepEmpty renders the section even when it has no games
+	KeepEmpty bool
+}
+
+// NewSection lists games released in the last d, newest first
+func NewSection(id, title string, d time.Duration, limit int) Section {
+	return Section{ID: id, Title: title, Filter: ReleasedWithin(d), Sort: Newest, Limit: limit}
+}
+
+// PopularSection lists games by popularity
+func PopularSection(id, title string, limit int) Section {
+	return Section{ID: id, Title: title, Filter: Popular(), Sort: MostPopular, Limit: limit}
+}
+
+// ProducerSection lists one producer's games
+func ProducerSection(id, title, producer string, sort SortOrder, limit int) Section {
+	return Section{ID: id, Title: title, Filter: ByProducer(producer), Sort: sort, Limit: limit}
+}
+
+// TypeSection lists the games of one type
+func TypeSection(id, title, gameType string, sort SortOrder, limit int) Section {
+	return Section{ID: id, Title: title, Filter: ByType(gameType), Sort: sort, Limit: limit}
+}
+
+// FeaturedSection lists hand-picked games in the given order
+func FeaturedSection(id, title string, ids ...int) Section {
+	return Section{ID: id, Title: title, Filter: IDs(ids...), Pinned: ids}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
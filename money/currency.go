@@ -0,0 +1,100 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownCurrency is returned for currency codes with no registered exponent
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// maxExponent bounds custom exponents so that one major unit fits in an int64
+const maxExponent = 18
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]int{}
+)
+
+func init() {
+	for _, code := range iso4217Default {
+		registry[code] = 2
+	}
+	for code, exp := range iso4217Exponents {
+		registry[code] = exp
+	}
+	for code, exp := range cryptoExponents {
+		registry[code] = exp
+	}
+}
+
+// Exponent returns the number of minor-unit digits for a currency code
+func Exponent(currency string) (int, error) {
+	registryMu.RLock()
+	exp, ok := registry[strings.ToUpper(currency)]
+	registryMu.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	return exp, nil
+}
+
+// IsKnown reports whether a currency code is registered
+func IsKnown(currency string) bool {
+	_, err := Exponent(currency)
+	return err == nil
+}
+
+// Register adds or replaces a currency, typically a crypto asset, with the
+// given number of minor-unit digits
+func Register(currency string, exponent int) error {
+	if currency == "" {
+		return errors.New("currency code is required")
+	}
+	if exponent < 0 || exponent > maxExponent {
+		return fmt.Errorf("exponent for %s must be between 0 and %d", currency, maxExponent)
+	}
+	registryMu.Lock()
+	registry[strings.ToUpper(currency)] = exponent
+	registryMu.Unlock()
+	return nil
+}
+
+// iso4217Default lists active ISO 4217 currencies with two minor-unit digits
+var iso4217Default = []string{
+	"AED", "AFN", "ALL", "AMD", "ANG", "AOA", "ARS", "AUD", "AWG", "AZN",
+	"BAM", "BBD", "BDT", "BGN", "BMD", "BND", "BOB", "BOV", "BRL", "BSD",
+	"BTN", "BWP", "BYN", "BZD", "CAD", "CDF", "CHE", "CHF", "CHW", "CNY",
+	"COP", "COU", "CRC", "CUP", "CVE", "CZK", "DKK", "DOP", "DZD", "EGP",
+	"ERN", "ETB", "EUR", "FJD", "FKP", "GBP", "GEL", "GHS", "GIP", "GMD",
+	"GTQ", "GYD", "HKD", "HNL", "HTG", "HUF", "IDR", "ILS", "INR", "IRR",
+	"JMD", "KES", "KGS", "KHR", "KPW", "KYD", "KZT", "LAK", "LBP", "LKR",
+	"LRD", "LSL", "MAD", "MDL", "MGA", "MKD", "MMK", "MNT", "MOP", "MRU",
+	"MUR", "MVR", "MWK", "MXN", "MXV", "MYR", "MZN", "NAD", "NGN", "NIO",
+	"NOK", "NPR", "NZD", "PAB", "PEN", "PGK", "PHP", "PKR", "PLN", "QAR",
+	"RON", "RSD", "RUB", "SAR", "SBD", "SCR", "SDG", "SEK", "SGD", "SHP",
+	"SLE", "SOS", "SRD", "SSP", "STN", "SVC", "SYP", "SZL", "THB", "TJS",
+	"TMT", "TOP", "TRY", "TTD", "TWD", "TZS", "UAH", "USD", "USN", "UYU",
+	"UZS", "VES", "WST", "XCD", "YER", "ZAR", "ZMW", "ZWG",
+}
+
+// iso4217Exponents lists ISO 4217 currencies whose minor unit is not two digits
+var iso4217Exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// cryptoExponents lists crypto assets supported out of the box
+var cryptoExponents = map[string]int{
+	"BTC":  8,
+	"LTC":  8,
+	"BCH":  8,
+	"DOGE": 8,
+	"USDT": 6,
+	"USDC": 6,
+}
@@ -0,0 +1,221 @@
+// Package money provides an exact, currency-aware amount in minor units
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCurrencyMismatch is returned when combining amounts in different currencies
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+
+	// ErrOverflow is returned when an operation exceeds the int64 range
+	ErrOverflow = errors.New("amount overflows int64")
+
+	// ErrInvalidAmount is returned when a decimal string cannot be parsed exactly
+	ErrInvalidAmount = errors.New("invalid amount")
+)
+
+// Money is an amount in the minor units of its currency (cents for USD,
+// yen for JPY, satoshis for BTC). Amounts range over ±math.MaxInt64;
+// math.MinInt64 is rejected like any other overflow, so Neg is always exact.
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// New creates an amount from minor units
+func New(minor int64, currency string) (Money, error) {
+	if _, err := Exponent(currency); err != nil {
+		return Money{}, err
+	}
+	if minor == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return Money{minor: minor, currency: strings.ToUpper(currency)}, nil
+}
+
+// MustNew is like New but panics on an unknown currency or math.MinInt64
+func MustNew(minor int64, currency string) Money {
+	m, err := New(minor, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Parse parses a decimal string in major units, such as "12.34", without
+// going through floating point. More fractional digits than the currency
+// allows is an error rather than a rounding.
+func Parse(amount, currency string) (Money, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	s := strings.TrimSpace(amount)
+	neg := false
+	if strings.HasPrefix(s, "-") {
+		neg = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, amount)
+	}
+	if len(frac) > exp {
+		if strings.TrimRight(frac[exp:], "0") != "" {
+			return Money{}, fmt.Errorf("%w %q: more than %d decimal places for %s", ErrInvalidAmount, amount, exp, currency)
+		}
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+
+	digits := whole + frac
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, amount)
+		}
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w %q", ErrOverflow, amount)
+	}
+	if neg {
+		minor = -minor
+	}
+	return Money{minor: minor, currency: strings.ToUpper(currency)}, nil
+}
+
+// FromMajor converts a floating-point amount in major units, rounding to the
+// nearest minor unit. Prefer New or Parse where the source allows it.
+func FromMajor(amount float64, currency string) (Money, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	// Format with the exact number of digits first so 0.29 becomes "0.29"
+	// rather than 28.999999999999996 minor units.
+	return Parse(strconv.FormatFloat(amount, 'f', exp, 64), currency)
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Currency returns the upper-case currency code
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// Exponent returns the number of minor-unit digits of the currency
+func (m Money) Exponent() int {
+	exp, _ := Exponent(m.currency)
+	return exp
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// Add returns m + o
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	sum := m.minor + o.minor
+	if (o.minor > 0 && sum < m.minor) || (o.minor < 0 && sum > m.minor) || sum == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return Money{minor: sum, currency: m.currency}, nil
+}
+
+// Sub returns m - o
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(Money{minor: -o.minor, currency: o.currency})
+}
+
+// Mul returns m multiplied by an integer factor
+func (m Money) Mul(n int64) (Money, error) {
+	if m.minor == 0 || n == 0 {
+		return Money{currency: m.currency}, nil
+	}
+	product := m.minor * n
+	if product/n != m.minor || product == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return Money{minor: product, currency: m.currency}, nil
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// Cmp compares two amounts, returning -1, 0 or +1
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.minor < o.minor:
+		return -1, nil
+	case m.minor > o.minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Decimal formats the amount in major units with the currency's exact
+// number of decimal places, e.g. "12.34", "1500" or "0.00012000"
+func (m Money) Decimal() string {
+	exp := m.Exponent()
+	minor := m.minor
+	sign := ""
+	var abs uint64
+	if minor < 0 {
+		sign = "-"
+		abs = uint64(-(minor + 1)) + 1
+	} else {
+		abs = uint64(minor)
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	cut := len(digits) - exp
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+// String formats the amount with its currency code, e.g. "12.34 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.currency
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.currency != o.currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Package pagination walks paged API listings as Go iterators
+package pagination
+
+import (
+	"context"
+	"iter"
+)
+
+// Request selects a page, by number or by the cursor the previous page
+// returned
+type Request struct {
+	Page   int
+	Cursor string
+}
+
+// Page is one page of results
+type Page[T any] struct {
+	Items []T
+	// Next requests the following page; nil on the last page
+	Next *Request
+}
+
+// Fetch loads the page described by req
+type Fetch[T any] func(ctx context.Context, req Request) (Page[T], error)
+
+type result[T any] struct {
+	page Page[T]
+	err  error
+}
+
+// All yields every item of every page, starting at first. Pages are fetched
+// lazily, but while the items of one page are being consumed the next page is
+// already loading. A fetch error is yielded once and ends the sequence.
+func All[T any](ctx context.Context, first Request, fetch Fetch[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		load := func(req Request) <-chan result[T] {
+			ch := make(chan result[T], 1)
+			go func() {
+				page, err := fetch(ctx, req)
+				ch <- result[T]{page, err}
+			}()
+			return ch
+		}
+
+		pending := load(first)
+		for pending != nil {
+			res := <-pending
+			if res.err != nil {
+				var zero T
+				yield(zero, res.err)
+				return
+			}
+
+			pending = nil
+			if res.page.Next != nil && len(res.page.Items) > 0 {
+				pending = load(*res.page.Next)
+			}
+			for _, item := range res.page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Collect gathers every item from seq, stopping at the first error
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,234 @@
+// Package responsible enforces responsible-gambling policies such as loss
+// limits, stake limits, play-time limits and self-exclusion before bets are
+// accepted and before games are launched.
+//
+// Deposit limits are not covered: deposits are made through the operator's
+// cashier and never reach the SDK, so they have to be enforced there. Net
+// loss limits bound the same spend from the game side.
+package responsible
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+)
+
+// ErrorCode is the error code returned to the provider when a policy blocks
+// a bet
+const ErrorCode = "PLAY_BLOCKED"
+
+// ErrBlocked is wrapped by every BlockedError
+var ErrBlocked = errors.New("play blocked by responsible gambling policy")
+
+// BlockedError is returned when a policy refuses a bet or session
+type BlockedError struct {
+	// Policy names the policy that blocked play, e.g. "daily_loss_limit"
+	Policy string
+	Reason string
+	// Until is when play may resume; zero when unknown or never
+	Until time.Time
+}
+
+// Error implements the error interface
+func (e *BlockedError) Error() string {
+	if e.Until.IsZero() {
+		return fmt.Sprintf("%s: %s", e.Policy, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s until %s", e.Policy, e.Reason, e.Until.UTC().Format(time.RFC3339))
+}
+
+// Unwrap returns ErrBlocked
+func (e *BlockedError) Unwrap() error {
+	return ErrBlocked
+}
+
+// Request kinds
+const (
+	KindBet     = "bet"
+	KindSession = "session"
+)
+
+// Request describes the play a policy is asked to allow
+type Request struct {
+	Kind     string
+	PlayerID string
+	Currency string
+	// Stake is the bet amount in minor units; zero for sessions
+	Stake    int64
+	Freespin bool
+	At       time.Time
+
+	// PlayStart is when the player's current stretch of continuous play
+	// began; zero if they are not playing
+	PlayStart time.Time
+	// BreakAfter is the inactivity that ends a stretch of play
+	BreakAfter time.Duration
+	// LastActivity is the player's latest bet or session start
+	LastActivity time.Time
+}
+
+// Policy decides whether a request may go ahead. Return a *BlockedError to
+// refuse it; any other error is treated as a failure to decide.
+type Policy interface {
+	Check(ctx context.Context, req Request, store Store) error
+}
+
+// PolicyFunc adapts a function to the Policy interface
+type PolicyFunc func(ctx context.Context, req Request, store Store) error
+
+// Check calls f
+func (f PolicyFunc) Check(ctx context.Context, req Request, store Store) error {
+	return f(ctx, req, store)
+}
+
+// Options configures a Guard
+type Options struct {
+	// Policies are checked in order; the first refusal wins
+	Policies []Policy
+	// Store holds per-player activity; defaults to a MemoryStore
+	Store Store
+	// BreakAfter is the inactivity that ends a stretch of continuous play;
+	// defaults to 30 minutes
+	BreakAfter time.Duration
+	// Now overrides the clock, mainly for tests
+	Now func() time.Time
+}
+
+// Guard checks policies and records the activity they depend on
+type Guard struct {
+	policies   []Policy
+	store      Store
+	breakAfter time.Duration
+	now        func() time.Time
+}
+
+// NewGuard creates a new guard
+func NewGuard(opts Options) *Guard {
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	if opts.BreakAfter <= 0 {
+		opts.BreakAfter = 30 * time.Minute
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Guard{
+		policies:   opts.Policies,
+		store:      opts.Store,
+		breakAfter: opts.BreakAfter,
+		now:        opts.Now,
+	}
+}
+
+// Store returns the guard's activity store
+func (g *Guard) Store() Store {
+	return g.store
+}
+
+// CheckBet asks every policy whether a bet may be accepted
+func (g *Guard) CheckBet(ctx context.Context, playerID string, stake money.Money, freespin bool) error {
+	req, err := g.request(ctx, KindBet, playerID, stake.Currency())
+	if err != nil {
+		return err
+	}
+	req.Stake = stake.Minor()
+	req.Freespin = freespin
+	return g.check(ctx, req)
+}
+
+// CheckSession asks every policy whether a game may be launched
+func (g *Guard) CheckSession(ctx context.Context, playerID, currency string) error {
+	req, err := g.request(ctx, KindSession, playerID, currency)
+	if err != nil {
+		return err
+	}
+	return g.check(ctx, req)
+}
+
+// RecordBet adds an accepted bet to the player's net loss and play time
+func (g *Guard) RecordBet(ctx context.Context, playerID string, stake money.Money) error {
+	if err := g.touch(ctx, playerID); err != nil {
+		return err
+	}
+	return g.store.AddLoss(ctx, playerID, stake.Currency(), stake.Minor(), g.now())
+}
+
+// RecordWin subtracts a win from the player's net loss
+func (g *Guard) RecordWin(ctx context.Context, playerID string, amount money.Money) error {
+	return g.store.AddLoss(ctx, playerID, amount.Currency(), -amount.Minor(), g.now())
+}
+
+// AdjustLoss changes the player's net loss without counting as play, e.g.
+// when a bet (negative) or win (positive) is rolled back
+func (g *Guard) AdjustLoss(ctx context.Context, playerID string, delta money.Money) error {
+	return g.store.AddLoss(ctx, playerID, delta.Currency(), delta.Minor(), g.now())
+}
+
+// RecordSessionStart counts a game launch as play
+func (g *Guard) RecordSessionStart(ctx context.Context, playerID string) error {
+	return g.touch(ctx, playerID)
+}
+
+// CoolOff blocks the player from playing for d
+func (g *Guard) CoolOff(ctx context.Context, playerID string, d time.Duration) error {
+	return g.store.SetExclusion(ctx, playerID, Exclusion{Until: g.now().Add(d)})
+}
+
+// SelfExclude blocks the player indefinitely until Lift is called
+func (g *Guard) SelfExclude(ctx context.Context, playerID string) error {
+	return g.store.SetExclusion(ctx, playerID, Exclusion{Permanent: true})
+}
+
+// Lift removes a cooling-off period or self-exclusion
+func (g *Guard) Lift(ctx context.Context, playerID string) error {
+	return g.store.ClearExclusion(ctx, playerID)
+}
+
+func (g *Guard) request(ctx context.Context, kind, playerID, currency string) (Request, error) {
+	now := g.now()
+	req := Request{
+		Kind:       kind,
+		PlayerID:   playerID,
+		Currency:   strings.ToUpper(currency),
+		At:         now,
+		BreakAfter: g.breakAfter,
+	}
+	play, ok, err := g.store.Play(ctx, playerID)
+	if err != nil {
+		return req, err
+	}
+	if ok {
+		req.LastActivity = play.Last
+		if now.Sub(play.Last) < g.breakAfter {
+			req.PlayStart = play.Start
+		}
+	}
+	return req, nil
+}
+
+func (g *Guard) check(ctx context.Context, req Request) error {
+	for _, p := range g.policies {
+		if err := p.Check(ctx, req, g.store); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Guard) touch(ctx context.Context, playerID string) error {
+	now := g.now()
+	play, ok, err := g.store.Play(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if !ok || now.Sub(play.Last) >= g.breakAfter {
+		play.Start = now
+	}
+	play.Last = now
+	return g.store.SetPlay(ctx, playerID, play)
+}
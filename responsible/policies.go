@@ -0,0 +1,118 @@
+package responsible
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+)
+
+// LossLimit caps a player's net loss (stakes minus wins) over a rolling
+// period. Bets that would take the loss past the limit are refused, and no
+// new session starts once it is reached.
+type LossLimit struct {
+	// Name identifies the policy in BlockedError; defaults to "loss_limit"
+	Name string
+	// Limit is the default cap; it only applies to bets in its currency
+	Limit money.Money
+	// Period is the rolling window, e.g. 24 hours
+	Period time.Duration
+	// PlayerLimit, if set, returns a player's own limit in place of Limit
+	PlayerLimit func(ctx context.Context, playerID string) (money.Money, bool)
+}
+
+// DailyLossLimit limits net loss over the last 24 hours
+func DailyLossLimit(limit money.Money) *LossLimit {
+	return &LossLimit{Name: "daily_loss_limit", Limit: limit, Period: 24 * time.Hour}
+}
+
+// WeeklyLossLimit limits net loss over the last 7 days
+func WeeklyLossLimit(limit money.Money) *LossLimit {
+	return &LossLimit{Name: "weekly_loss_limit", Limit: limit, Period: 7 * 24 * time.Hour}
+}
+
+// Check implements Policy
+func (p *LossLimit) Check(ctx context.Context, req Request, store Store) error {
+	limit := p.Limit
+	if p.PlayerLimit != nil {
+		if l, ok := p.PlayerLimit(ctx, req.PlayerID); ok {
+			limit = l
+		}
+	}
+	if !strings.EqualFold(limit.Currency(), req.Currency) {
+		return nil
+	}
+
+	loss, err := store.NetLoss(ctx, req.PlayerID, limit.Currency(), req.At.Add(-p.Period))
+	if err != nil {
+		return err
+	}
+	if loss+req.Stake > limit.Minor() || (req.Kind == KindSession && loss >= limit.Minor()) {
+		return &BlockedError{
+			Policy: nameOr(p.Name, "loss_limit"),
+			Reason: fmt.Sprintf("net loss limit of %s per %s reached", limit, p.Period),
+		}
+	}
+	return nil
+}
+
+// MaxStake refuses single bets above a limit. Freespin bets are exempt.
+type MaxStake struct {
+	// Limit only applies to bets in its currency
+	Limit money.Money
+}
+
+// Check implements Policy
+func (p *MaxStake) Check(ctx context.Context, req Request, store Store) error {
+	if req.Kind != KindBet || req.Freespin || !strings.EqualFold(req.Currency, p.Limit.Currency()) {
+		return nil
+	}
+	if req.Stake > p.Limit.Minor() {
+		return &BlockedError{Policy: "max_stake", Reason: fmt.Sprintf("stake above maximum of %s", p.Limit)}
+	}
+	return nil
+}
+
+// RealityCheck limits continuous play. Once a player has played for Limit
+// without a break, bets and new sessions are refused until they have been
+// inactive for the guard's BreakAfter.
+type RealityCheck struct {
+	Limit time.Duration
+}
+
+// Check implements Policy
+func (p *RealityCheck) Check(ctx context.Context, req Request, store Store) error {
+	if req.PlayStart.IsZero() || req.At.Sub(req.PlayStart) < p.Limit {
+		return nil
+	}
+	return &BlockedError{
+		Policy: "reality_check",
+		Reason: fmt.Sprintf("played for more than %s without a break", p.Limit),
+		Until:  req.LastActivity.Add(req.BreakAfter),
+	}
+}
+
+// CoolingOff refuses all play during a cooling-off period or self-exclusion
+// set with Guard.CoolOff or Guard.SelfExclude
+type CoolingOff struct{}
+
+// Check implements Policy
+func (CoolingOff) Check(ctx context.Context, req Request, store Store) error {
+	e, ok, err := store.Exclusion(ctx, req.PlayerID)
+	if err != nil || !ok || !e.Active(req.At) {
+		return err
+	}
+	if e.Permanent {
+		return &BlockedError{Policy: "self_exclusion", Reason: "player is self-excluded"}
+	}
+	return &BlockedError{Policy: "cooling_off", Reason: "player is in a cooling-off period", Until: e.Until}
+}
+
+func nameOr(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
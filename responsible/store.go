@@ -0,0 +1,141 @@
+package responsible
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Play is a player's current or latest stretch of continuous play
+type Play struct {
+	Start time.Time
+	Last  time.Time
+}
+
+// Exclusion is a cooling-off period or, if Permanent, a self-exclusion
+type Exclusion struct {
+	Until     time.Time
+	Permanent bool
+}
+
+// Active reports whether the exclusion still applies at t
+func (e Exclusion) Active(t time.Time) bool {
+	return e.Permanent || t.Before(e.Until)
+}
+
+// Store holds the per-player activity policies depend on. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// AddLoss records a change in net loss (stake positive, win negative)
+	AddLoss(ctx context.Context, playerID, currency string, amount int64, at time.Time) error
+	// NetLoss sums the changes recorded at or after since
+	NetLoss(ctx context.Context, playerID, currency string, since time.Time) (int64, error)
+
+	Play(ctx context.Context, playerID string) (Play, bool, error)
+	SetPlay(ctx context.Context, playerID string, play Play) error
+
+	Exclusion(ctx context.Context, playerID string) (Exclusion, bool, error)
+	SetExclusion(ctx context.Context, playerID string, e Exclusion) error
+	ClearExclusion(ctx context.Context, playerID string) error
+}
+
+type lossEntry struct {
+	currency string
+	amount   int64
+	at       time.Time
+}
+
+// MemoryStore is an in-process Store. Loss entries older than the retention
+// are discarded, so it must be at least the longest loss-limit period.
+type MemoryStore struct {
+	mu         sync.Mutex
+	retention  time.Duration
+	losses     map[string][]lossEntry
+	plays      map[string]Play
+	exclusions map[string]Exclusion
+}
+
+// NewMemoryStore creates an empty store keeping 31 days of losses
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithRetention(31 * 24 * time.Hour)
+}
+
+// NewMemoryStoreWithRetention creates an empty store keeping losses for the
+// given duration
+func NewMemoryStoreWithRetention(retention time.Duration) *MemoryStore {
+	return &MemoryStore{
+		retention:  retention,
+		losses:     make(map[string][]lossEntry),
+		plays:      make(map[string]Play),
+		exclusions: make(map[string]Exclusion),
+	}
+}
+
+// AddLoss implements Store
+func (s *MemoryStore) AddLoss(ctx context.Context, playerID, currency string, amount int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.losses[playerID]
+	cutoff := at.Add(-s.retention)
+	i := 0
+	for i < len(entries) && entries[i].at.Before(cutoff) {
+		i++
+	}
+	s.losses[playerID] = append(entries[i:], lossEntry{currency: currency, amount: amount, at: at})
+	return nil
+}
+
+// NetLoss implements Store
+func (s *MemoryStore) NetLoss(ctx context.Context, playerID, currency string, since time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total int64
+	for _, e := range s.losses[playerID] {
+		if e.currency == currency && !e.at.Before(since) {
+			total += e.amount
+		}
+	}
+	return total, nil
+}
+
+// Play implements Store
+func (s *MemoryStore) Play(ctx context.Context, playerID string) (Play, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.plays[playerID]
+	return p, ok, nil
+}
+
+// SetPlay implements Store
+func (s *MemoryStore) SetPlay(ctx context.Context, playerID string, play Play) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plays[playerID] = play
+	return nil
+}
+
+// Exclusion implements Store
+func (s *MemoryStore) Exclusion(ctx context.Context, playerID string) (Exclusion, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.exclusions[playerID]
+	return e, ok, nil
+}
+
+// SetExclusion implements Store
+func (s *MemoryStore) SetExclusion(ctx context.Context, playerID string, e Exclusion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exclusions[playerID] = e
+	return nil
+}
+
+// ClearExclusion implements Store
+func (s *MemoryStore) ClearExclusion(ctx context.Context, playerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.exclusions, playerID)
+	return nil
+}
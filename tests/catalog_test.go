@@ -0,0 +1,260 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/catalog"
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+)
+
+func TestGameCatalogCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	down := false
+	source := func(ctx context.Context, etag string) ([]flows.Game, string, error) {
+		switch {
+		case down:
+			return nil, "", errors.New("api unavailable")
+		case etag == `"v1"`:
+			return nil, etag, flows.ErrNotModified
+		}
+		return []flows.Game{
+			{ID: 1, Title: "Sweet Bonanza", Producer: "Pragmatic", Type: "slots"},
+			{ID: 2, Title: "Gates of Olympus", Producer: "Pragmatic", Type: "slots"},
+			{ID: 3, Title: "Lightning Roulette", Producer: "Evolution", Type: "live"},
+		}, `"v1"`, nil
+	}
+
+	catalog := flows.NewCatalog(nil, flows.CatalogOptions{
+		TTL:      time.Minute,
+		MaxStale: time.Hour,
+		Source:   source,
+		Now:      func() time.Time { return now },
+	})
+
+	// Cached games carry no producer ID or provider slug to filter on
+	for _, params := range []flows.ListParams{{ProducerID: 1}, {Provider: "pragmatic"}, {Cursor: "abc"}} {
+		if resp := catalog.List(ctx, params); resp.Success || !strings.Contains(resp.Error, flows.ErrUnsupportedParams.Error()) {
+			t.Errorf("Expected ErrUnsupportedParams for %+v, got %+v", params, resp)
+		}
+	}
+	if stats := catalog.Stats(); stats.Refreshes != 0 {
+		t.Errorf("Expected unsupported params to fail without loading, got %+v", stats)
+	}
+
+	resp := catalog.List(ctx, flows.ListParams{Type: "SLOTS", PerPage: 1, Page: 2})
+	if !resp.Success || len(resp.Games) != 1 || resp.Games[0].ID != 2 || resp.Meta.LastPage != 2 {
+		t.Errorf("Unexpected list response: %+v", resp)
+	}
+	if game, ok, err := catalog.Game(ctx, 3); err != nil || !ok || game.Title != "Lightning Roulette" {
+		t.Errorf("Expected game 3, got %+v, %v, %v", game, ok, err)
+	}
+	if stats := catalog.Stats(); stats.Misses != 1 || stats.Hits != 1 || stats.Size != 3 || stats.ETag != `"v1"` {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// Expired: revalidated with the ETag
+	now = now.Add(2 * time.Minute)
+	if _, err := catalog.Games(ctx); err != nil {
+		t.Fatalf("Games failed: %v", err)
+	}
+	if stats := catalog.Stats(); stats.NotModified != 1 || stats.Size != 3 {
+		t.Errorf("Expected a not-modified refresh, got %+v", stats)
+	}
+
+	// API down: stale data is served until MaxStale runs out
+	down = true
+	now = now.Add(2 * time.Minute)
+	if games, err := catalog.Games(ctx); err != nil || len(games) != 3 {
+		t.Errorf("Expected stale catalog, got %d games, %v", len(games), err)
+	}
+	if stats := catalog.Stats(); stats.StaleHits != 1 || stats.RefreshErrors != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := catalog.Games(ctx); !errors.Is(err, flows.ErrCatalogUnavailable) {
+		t.Errorf("Expected ErrCatalogUnavailable, got %v", err)
+	}
+}
+
+func TestCatalogSyncer(t *testing.T) {
+	ctx := context.Background()
+	current := []flows.Game{
+		{ID: 1, Title: "Sweet Bonanza", Producer: "Pragmatic"},
+		{ID: 2, Title: "Gates of Olympus", Producer: "Pragmatic"},
+	}
+	var events []catalog.Change
+	failing := false
+
+	syncer := catalog.NewSyncer(nil, catalog.Options{
+		Store: catalog.NewFileSnapshotStore(filepath.Join(t.TempDir(), "catalog.json")),
+		Fetch: func(ctx context.Context) ([]flows.Game, error) { return current, nil },
+		OnChange: func(ctx context.Context, change catalog.Change) error {
+			if failing {
+				return errors.New("database unavailable")
+			}
+			events = append(events, change)
+			return nil
+		},
+	})
+
+	// First sync: everything is new
+	report, err := syncer.Sync(ctx)
+	if err != nil || report.Count(catalog.Added) != 2 || len(events) != 2 {
+		t.Fatalf("Expected 2 added games, got %+v, %v", report, err)
+	}
+
+	current = []flows.Game{
+		{ID: 1, Title: "Sweet Bonanza 1000", Producer: "Pragmatic", HasDemo: true},
+		{ID: 3, Title: "Lightning Roulette", Producer: "Evolution"},
+	}
+
+	// Dry run reports without emitting or saving
+	events = nil
+	report, err = syncer.DryRun(ctx)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("Dry run failed or emitted events: %v, %v", err, events)
+	}
+	kinds := []catalog.ChangeKind{catalog.Added, catalog.Modified, catalog.Removed}
+	for i, change := range report.Changes {
+		if change.Kind != kinds[i] {
+			t.Errorf("Change %d: expected %s, got %s", i, kinds[i], change.Kind)
+		}
+	}
+	if fields := strings.Join(report.Changes[1].Fields, ","); fields != "title,has_demo" {
+		t.Errorf("Expected title,has_demo modified, got %s", fields)
+	}
+	var text strings.Builder
+	report.WriteTo(&text)
+	if !strings.Contains(text.String(), "1 added, 1 modified, 1 removed") || !strings.Contains(text.String(), "- 2 Gates of Olympus") {
+		t.Errorf("Unexpected report:\n%s", text.String())
+	}
+
+	// A failed event leaves the snapshot alone, so the changes come again
+	failing = true
+	if _, err := syncer.Sync(ctx); err == nil {
+		t.Error("Expected sync to fail")
+	}
+	failing = false
+	if report, err := syncer.Sync(ctx); err != nil || len(events) != 3 || len(report.Changes) != 3 {
+		t.Errorf("Expected the 3 changes to be emitted, got %v, %v", events, err)
+	}
+	if report, _ := syncer.Sync(ctx); report.HasChanges() {
+		t.Errorf("Expected no changes, got %v", report.Changes)
+	}
+}
+
+// shiftingCatalog pages through ids two at a time by offset, calling
+// afterFirst once the first page has been served
+type shiftingCatalog struct {
+	mu         sync.Mutex
+	ids        []int
+	afterFirst func([]int) []int
+}
+
+func (c *shiftingCatalog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+	var data []map[string]any
+	for _, id := range c.ids[min((page-1)*2, len(c.ids)):min(page*2, len(c.ids))] {
+		data = append(data, map[string]any{"id": id, "title": "Game " + strconv.Itoa(id)})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data": data,
+		"meta": map[string]any{"total": len(c.ids), "per_page": 2, "current_page": page, "last_page": (len(c.ids) + 1) / 2},
+	})
+	if page == 1 && c.afterFirst != nil {
+		c.ids = c.afterFirst(c.ids)
+	}
+}
+
+func TestCatalogSyncerShiftedPages(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name       string
+		afterFirst func([]int) []int
+		wantErr    bool
+	}{
+		{name: "stable"},
+		// Game 3 slides onto page 1 and is never listed
+		{name: "removed", afterFirst: func(ids []int) []int { return ids[1:] }, wantErr: true},
+		// Same total, but game 2 is listed twice and game 5 is gone
+		{name: "replaced", afterFirst: func(ids []int) []int { return append([]int{0}, ids[:4]...) }, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &shiftingCatalog{ids: []int{1, 2, 3, 4, 5}, afterFirst: tc.afterFirst}
+			store := catalog.NewMemorySnapshotStore()
+			syncer := catalog.NewSyncer(flows.NewGamesFlow(newFakeAPI(t, api)), catalog.Options{
+				Params: flows.ListParams{PerPage: 2},
+				Store:  store,
+			})
+
+			report, err := syncer.Sync(ctx)
+			_, saved, _ := store.Load(ctx)
+			if !tc.wantErr {
+				if err != nil || report.Total != 5 || !saved {
+					t.Errorf("Expected 5 games saved, got %+v, %v", report, err)
+				}
+				return
+			}
+			if !errors.Is(err, catalog.ErrIncompleteCatalog) || saved {
+				t.Errorf("Expected ErrIncompleteCatalog and no snapshot, got %v (saved %v)", err, saved)
+			}
+		})
+	}
+}
+
+func TestCatalogSearchIndex(t *testing.T) {
+	index := catalog.NewIndex([]flows.Game{
+		{ID: 1, Title: "Sweet Bonanza", Producer: "Pragmatic", Type: "slots", HasDemo: true},
+		{ID: 2, Title: "Sweet Bonanza 1000", Producer: "Pragmatic", Type: "slots"},
+		{ID: 3, Title: "Gates of Olympus", Producer: "Pragmatic", Type: "slots", HasDemo: true},
+		{ID: 4, Title: "Lightning Roulette", Producer: "Evolution", Type: "live"},
+		{ID: 5, Title: "Starburst", Producer: "NetEnt", Type: "slots", HasDemo: true},
+	})
+
+	// Typos are tolerated unless Exact is set
+	if res := index.Search(catalog.Query{Text: "bonanxa"}); res.Total != 2 || res.Hits[0].Game.ID != 1 {
+		t.Errorf("Expected both Bonanzas, shortest first, got %+v", res.Hits)
+	}
+	if res := index.Search(catalog.Query{Text: "olmypus"}); res.Total != 1 || res.Hits[0].Game.ID != 3 {
+		t.Errorf("Expected Gates of Olympus, got %+v", res.Hits)
+	}
+	if res := index.Search(catalog.Query{Text: "olmypus", Exact: true}); res.Total != 0 {
+		t.Errorf("Expected no exact match, got %+v", res.Hits)
+	}
+
+	// The last word matches as a prefix
+	if titles := index.Suggest("sweet bo", 5); len(titles) != 2 || titles[0] != "Sweet Bonanza" {
+		t.Errorf("Unexpected suggestions: %v", titles)
+	}
+	if res := index.Search(catalog.Query{Text: "light"}); res.Total != 1 || res.Hits[0].Game.ID != 4 {
+		t.Errorf("Expected Lightning Roulette, got %+v", res.Hits)
+	}
+
+	// Facet counts ignore their own filter
+	res := index.Search(catalog.Query{Filters: map[string][]string{
+		catalog.FacetProducer: {"pragmatic"},
+		catalog.FacetDemo:     {"true"},
+	}})
+	if res.Total != 2 {
+		t.Errorf("Expected 2 Pragmatic demo games, got %d", res.Total)
+	}
+	producers := res.Facets[catalog.FacetProducer]
+	if len(producers) != 2 || producers[0] != (catalog.FacetValue{Value: "Pragmatic", Count: 2}) || producers[1].Value != "NetEnt" {
+		t.Errorf("Unexpected producer facet: %v", producers)
+	}
+	if demo := res.Facets[catalog.FacetDemo]; len(demo) != 2 || demo[1] != (catalog.FacetValue{Value: "false", Count: 1}) {
+		t.Errorf("Unexpected demo facet: %v", demo)
+	}
+}
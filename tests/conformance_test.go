@@ -0,0 +1,21 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks/conformance"
+)
+
+func TestRouterPassesConformance(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	wallet := newTestWallet(map[string]int64{"p1": 100000})
+	router := webhooks.NewRouter(handler, wallet, webhooks.RouterOptions{})
+
+	conformance.RunTest(t, conformance.Config{
+		Secret:   webhookSecret,
+		Handler:  router,
+		PlayerID: "p1",
+		Currency: "USD",
+	})
+}
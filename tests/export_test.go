@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/catalog"
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+	"github.com/iplaygamesai/sdk-wrapper-go/lobby"
+)
+
+func TestCatalogExport(t *testing.T) {
+	games := []flows.Game{
+		{ID: 1, Title: `Sweet "Bonanza"`, Producer: "Pragmatic", Type: "slots", HasDemo: true},
+		{ID: 2, Title: "=HYPERLINK(\"x\")", Producer: "Fish & Chips", Type: "slots"},
+	}
+
+	var buf bytes.Buffer
+	n, err := catalog.Export(&buf, catalog.CSV, catalog.Games(games))
+	if err != nil || n != 2 {
+		t.Fatalf("CSV export failed: %d, %v", n, err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "id,title,producer,type,image_url,has_demo" || lines[1] != `1,"Sweet ""Bonanza""",Pragmatic,slots,,true` {
+		t.Errorf("Unexpected CSV:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[2], `2,"'=HYPERLINK`) {
+		t.Errorf("Expected formula to be escaped, got %s", lines[2])
+	}
+
+	cols, err := catalog.Columns("title", "id")
+	if err != nil {
+		t.Fatalf("Columns failed: %v", err)
+	}
+	buf.Reset()
+	catalog.Export(&buf, catalog.JSONL, catalog.Games(games), cols...)
+	if first := strings.SplitN(buf.String(), "\n", 2)[0]; first != `{"title":"Sweet \"Bonanza\"","id":1}` {
+		t.Errorf("Unexpected JSON line: %s", first)
+	}
+	if _, err := catalog.Columns("rtp"); !errors.Is(err, catalog.ErrUnknownColumn) {
+		t.Errorf("Expected ErrUnknownColumn, got %v", err)
+	}
+
+	buf.Reset()
+	if n, err := catalog.Export(&buf, catalog.XLSX, catalog.Games(games)); err != nil || n != 2 {
+		t.Fatalf("XLSX export failed: %d, %v", n, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("XLSX is not a zip archive: %v", err)
+	}
+	for _, f := range archive.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		sheet, _ := io.ReadAll(rc)
+		rc.Close()
+		if !strings.Contains(string(sheet), `<c r="A2"><v>1</v></c>`) || !strings.Contains(string(sheet), "Fish &amp; Chips") {
+			t.Errorf("Unexpected sheet: %s", sheet)
+		}
+	}
+}
+
+func TestCatalogExportXLSXError(t *testing.T) {
+	boom := errors.New("page 2 failed")
+	games := func(yield func(flows.Game, error) bool) {
+		if yield(flows.Game{ID: 1, Title: "Starburst"}, nil) {
+			yield(flows.Game{}, boom)
+		}
+	}
+
+	var buf bytes.Buffer
+	if n, err := catalog.ExportXLSX(&buf, games); !errors.Is(err, boom) || n != 1 {
+		t.Fatalf("Expected the iterator error after 1 row, got %d, %v", n, err)
+	}
+	// The archive is still complete, with the rows written before the error
+	if _, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+		t.Errorf("Expected a readable archive, got %v", err)
+	}
+}
+
+func TestLobbyCurationImport(t *testing.T) {
+	curated := "\ufeffID,Title,Pinned,Pin_Order,Hidden,Categories\n" +
+		"1,Sweet Bonanza,yes,,,Summer Hits!;Jackpots\n" +
+		"2,Gates of Olympus,x,1,,jackpots\n" +
+		"3,Starburst,,,1,\n"
+	curation, err := lobby.ImportCSV(strings.NewReader(curated))
+	if err != nil {
+		t.Fatalf("ImportCSV failed: %v", err)
+	}
+	if len(curation.Pinned) != 2 || curation.Pinned[0] != 2 || curation.Pinned[1] != 1 {
+		t.Errorf("Expected pins ordered by pin_order, got %v", curation.Pinned)
+	}
+	if len(curation.Hidden) != 1 || curation.Hidden[0] != 3 {
+		t.Errorf("Unexpected hidden games: %v", curation.Hidden)
+	}
+	if len(curation.Categories) != 2 || curation.Categories[1].Name != "Jackpots" || len(curation.Categories[1].Games) != 2 {
+		t.Errorf("Unexpected categories: %+v", curation.Categories)
+	}
+
+	opts := curation.Apply(lobby.Options{Sections: []lobby.Section{{ID: "all", Title: "All Games"}}})
+	model := lobby.New(opts).Build([]flows.Game{
+		{ID: 1, Title: "Sweet Bonanza"}, {ID: 2, Title: "Gates of Olympus"},
+		{ID: 3, Title: "Starburst"}, {ID: 4, Title: "Book of Dead"},
+	}, lobby.Viewer{})
+	if len(model.Sections) != 3 || model.Sections[1].ID != "summer-hits" {
+		t.Fatalf("Unexpected sections: %+v", model.Sections)
+	}
+	if all := model.Sections[0].Games; len(all) != 3 || all[0].ID != 2 || all[1].ID != 1 || all[2].ID != 4 {
+		t.Errorf("Expected pinned games first and Starburst hidden, got %+v", all)
+	}
+
+	// Names that slug alike, or not at all, still get distinct section IDs
+	clash := &lobby.Curation{Categories: []lobby.Category{
+		{Name: "Summer Hits", Games: []int{1}}, {Name: "summer-hits!", Games: []int{2}},
+		{Name: "★★★", Games: []int{3}}, {Name: "All", Games: []int{4}},
+	}}
+	var ids []string
+	for _, s := range clash.Apply(lobby.Options{Sections: []lobby.Section{{ID: "all"}}}).Sections {
+		ids = append(ids, s.ID)
+	}
+	if got := strings.Join(ids, ","); got != "all,summer-hits,summer-hits-2,category-3,all-2" {
+		t.Errorf("Unexpected section IDs: %s", got)
+	}
+
+	var importErr *lobby.ImportError
+	if _, err := lobby.ImportCSV(strings.NewReader("id,hidden\n1,maybe\n")); !errors.As(err, &importErr) || importErr.Line != 2 {
+		t.Errorf("Expected an ImportError on line 2, got %v", err)
+	}
+	if _, err := lobby.ImportCSV(strings.NewReader("title\nStarburst\n")); !errors.Is(err, lobby.ErrMissingIDColumn) {
+		t.Errorf("Expected ErrMissingIDColumn, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/freespins"
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
+)
+
+func TestFreespinCampaignCompletes(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	var finished []freespins.Campaign
+	tracker := freespins.NewTracker(freespins.Options{
+		OnFinished: func(c freespins.Campaign) { finished = append(finished, c) },
+	})
+
+	if _, err := tracker.RecordGrant(freespins.Grant{ID: "fs_1", PlayerID: "p1", GameID: 7, Currency: "USD", Count: 2}); err != nil {
+		t.Fatalf("Failed to record grant: %v", err)
+	}
+
+	spins := []string{
+		`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":0,"round_id":"r1","is_freespin":true,"freespin_id":"fs_1","freespins_remaining":1}`,
+		`{"type":"win","player_id":"p1","currency":"USD","transaction_id":2,"amount":250,"round_id":"r1","is_freespin":true,"freespin_id":"fs_1","freespins_remaining":1}`,
+		// A redelivered win is counted once
+		`{"type":"win","player_id":"p1","currency":"USD","transaction_id":2,"amount":250,"round_id":"r1","is_freespin":true,"freespin_id":"fs_1","freespins_remaining":1}`,
+		`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":3,"amount":0,"round_id":"r2","is_freespin":true,"freespin_id":"fs_1","freespins_remaining":0}`,
+		`{"type":"win","player_id":"p1","currency":"USD","transaction_id":4,"amount":100,"round_id":"r2","is_freespin":true,"freespin_id":"fs_1","freespins_remaining":0}`,
+	}
+	for _, body := range spins {
+		p, err := handler.Parse(body)
+		if err != nil {
+			t.Fatalf("Failed to parse payload: %v", err)
+		}
+		if _, ok := tracker.Track(p); !ok {
+			t.Fatalf("Freespin payload was not tracked: %s", body)
+		}
+	}
+
+	if len(finished) != 1 {
+		t.Fatalf("Expected one finished campaign, got %d", len(finished))
+	}
+	c := finished[0]
+	if c.Status != freespins.StatusCompleted || c.Played != 2 || c.Winnings != 350 {
+		t.Errorf("Unexpected campaign state: %+v", c)
+	}
+	if len(tracker.Active()) != 0 {
+		t.Error("Completed campaign should not be active")
+	}
+}
+
+func TestFreespinCampaignExpires(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := freespins.NewTracker(freespins.Options{Now: func() time.Time { return now }})
+
+	tracker.RecordGrant(freespins.Grant{ID: "fs_2", PlayerID: "p1", Count: 10, ExpiresAt: now.Add(24 * time.Hour)})
+
+	if finished := tracker.Check(); len(finished) != 0 {
+		t.Errorf("Campaign should not expire early, got %v", finished)
+	}
+	now = now.Add(25 * time.Hour)
+	finished := tracker.Check()
+	if len(finished) != 1 || finished[0].Status != freespins.StatusExpired {
+		t.Errorf("Expected expired campaign, got %v", finished)
+	}
+}
+
+func TestFreespinCampaignRetention(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := freespins.NewTracker(freespins.Options{Retention: time.Hour, Now: func() time.Time { return now }})
+
+	tracker.RecordGrant(freespins.Grant{ID: "fs_1", PlayerID: "p1", Count: 10, ExpiresAt: now.Add(time.Minute)})
+	tracker.RecordGrant(freespins.Grant{ID: "fs_2", PlayerID: "p1", Count: 10})
+	now = now.Add(2 * time.Minute)
+	if finished := tracker.Check(); len(finished) != 1 {
+		t.Fatalf("Expected fs_1 to expire, got %v", finished)
+	}
+
+	// Finished campaigns are kept for the retention period, running ones
+	// until they finish
+	now = now.Add(30 * time.Minute)
+	tracker.Check()
+	if _, ok := tracker.Campaign("fs_1"); !ok {
+		t.Error("Expected fs_1 to be kept within the retention period")
+	}
+	now = now.Add(time.Hour)
+	tracker.Check()
+	if _, ok := tracker.Campaign("fs_1"); ok {
+		t.Error("Expected fs_1 to be removed after the retention period")
+	}
+	if _, ok := tracker.Campaign("fs_2"); !ok {
+		t.Error("Expected the running campaign to be kept")
+	}
+	if n := tracker.Prune(now); n != 0 {
+		t.Errorf("Expected nothing left to prune, removed %d", n)
+	}
+}
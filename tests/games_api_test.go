@@ -0,0 +1,494 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apiclient "github.com/iplaygamesai/api-client-go"
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+	"github.com/iplaygamesai/sdk-wrapper-go/pagination"
+)
+
+// newFakeAPI serves handler as the API and returns a client pointed at it
+func newFakeAPI(t *testing.T, handler http.Handler) *apiclient.APIClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cfg := apiclient.NewConfiguration()
+	cfg.Servers = apiclient.ServerConfigurations{{URL: srv.URL}}
+	cfg.AddDefaultHeader("Authorization", "Bearer test")
+	return apiclient.NewAPIClient(cfg)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// gamesServer pages through count games, perPage at a time, and records the
+// query of every request
+type gamesServer struct {
+	mu      sync.Mutex
+	count   int
+	perPage int
+	queries []string
+}
+
+func (s *gamesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/games" || r.Header.Get("Authorization") != "Bearer test" {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	s.queries = append(s.queries, r.URL.RawQuery)
+	s.mu.Unlock()
+
+	page := 1
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		page, _ = strconv.Atoi(c)
+	} else if p := r.URL.Query().Get("page"); p != "" {
+		page, _ = strconv.Atoi(p)
+	}
+	lastPage := (s.count + s.perPage - 1) / s.perPage
+	var data []map[string]any
+	for id := (page-1)*s.perPage + 1; id <= min(page*s.perPage, s.count); id++ {
+		data = append(data, map[string]any{"id": id, "title": "Game " + strconv.Itoa(id), "producer": "Acme", "type": "slots"})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data": data,
+		"meta": map[string]any{"total": s.count, "per_page": s.perPage, "current_page": page, "last_page": lastPage},
+	})
+}
+
+func (s *gamesServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func TestGamesListPaging(t *testing.T) {
+	ctx := context.Background()
+	srv := &gamesServer{count: 5, perPage: 2}
+	games := flows.NewGamesFlow(newFakeAPI(t, srv))
+
+	first := games.List(ctx, flows.ListParams{PerPage: 2, Type: "slots"})
+	if !first.Success || len(first.Games) != 2 || first.Games[0].Title != "Game 1" {
+		t.Fatalf("Unexpected first page: %+v", first)
+	}
+	if first.Meta.Total != 5 || first.Meta.LastPage != 3 || !first.Meta.HasMore() {
+		t.Errorf("Unexpected meta: %+v", first.Meta)
+	}
+
+	third := games.List(ctx, flows.ListParams{PerPage: 2, Type: "slots", Page: 3})
+	if !third.Success || len(third.Games) != 1 || third.Games[0].ID != 5 || third.Meta.HasMore() {
+		t.Errorf("Unexpected last page: %+v", third)
+	}
+	if q := srv.requests()[1]; q != "page=3&per_page=2&type=slots" {
+		t.Errorf("Unexpected page query %q", q)
+	}
+
+	byCursor := games.List(ctx, flows.ListParams{PerPage: 2, Cursor: "2"})
+	if !byCursor.Success || len(byCursor.Games) != 2 || byCursor.Games[0].ID != 3 {
+		t.Errorf("Unexpected cursor page: %+v", byCursor)
+	}
+
+	all, err := pagination.Collect(games.All(ctx, flows.ListParams{PerPage: 2}))
+	if err != nil {
+		t.Fatalf("All failed: %v", err)
+	}
+	if len(all) != 5 || all[0].ID != 1 || all[4].ID != 5 {
+		t.Errorf("Expected games 1..5, got %+v", all)
+	}
+	if n := len(srv.requests()); n != 6 {
+		t.Errorf("Expected 3 page requests for All, got %d in total", n)
+	}
+}
+
+func TestProducersAPI(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	producers := flows.NewProducersFlow(newFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/api/v1/producers":
+			writeJSON(w, http.StatusOK, map[string]any{"data": []map[string]any{
+				{"id": 1, "name": "Pragmatic Play", "slug": "pragmatic", "provider": "Direct", "games_count": 2},
+				{"id": 2, "name": "NetEnt", "provider": "Evolution Group"},
+			}})
+		case "/api/v1/producers/1":
+			writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"id": 1, "name": "Pragmatic Play", "slug": "pragmatic"}})
+		case "/api/v1/producers/2":
+			writeJSON(w, http.StatusOK, map[string]any{"id": 2, "name": "NetEnt"})
+		case "/api/v1/producers/3":
+			writeJSON(w, http.StatusNotFound, map[string]any{"message": "Not found"})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"message": "boom"})
+		}
+	})))
+
+	list := producers.List(ctx)
+	if !list.Success || len(list.Producers) != 2 || list.Producers[0].Slug != "pragmatic" || list.Producers[0].GamesCount != 2 || list.Producers[1].Provider != "Evolution Group" {
+		t.Errorf("Unexpected producers: %+v", list)
+	}
+
+	// Get asks for the one producer, not the whole list
+	mu.Lock()
+	paths = nil
+	mu.Unlock()
+	if p, ok, err := producers.Get(ctx, 1); err != nil || !ok || p.Name != "Pragmatic Play" || p.Slug != "pragmatic" {
+		t.Errorf("Expected producer 1, got %+v, %v, %v", p, ok, err)
+	}
+	if p, ok, err := producers.Get(ctx, 2); err != nil || !ok || p.Name != "NetEnt" {
+		t.Errorf("Expected a bare producer 2, got %+v, %v, %v", p, ok, err)
+	}
+	if _, ok, err := producers.Get(ctx, 3); ok || err != nil {
+		t.Errorf("Expected producer 3 to be not found, got %v, %v", ok, err)
+	}
+	if _, ok, err := producers.Get(ctx, 4); ok || err == nil {
+		t.Errorf("Expected a server error for producer 4, got %v, %v", ok, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(paths, ",") != "/api/v1/producers/1,/api/v1/producers/2,/api/v1/producers/3,/api/v1/producers/4" {
+		t.Errorf("Unexpected requests: %v", paths)
+	}
+}
+
+// availabilityServer serves game details and session starts, recording the
+// country of every session started
+type availabilityServer struct {
+	mu        sync.Mutex
+	countries []string
+}
+
+func (s *availabilityServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/v1/games/7":
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"id": 7, "title": "Sweet Bonanza", "currencies": []string{"USD", "EUR"}, "restricted_countries": []string{"GB"},
+		}})
+	case "/api/v1/games/8":
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"id": 8, "title": "Gates of Olympus", "has_demo": true, "restricted_countries": []string{"US"},
+		}})
+	case "/api/v1/games/11":
+		// Metadata codes are not always upper case
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"id": 11, "title": "Le Bandit", "has_demo": true, "currencies": []string{"eur"}, "restricted_countries": []string{"gb"},
+		}})
+	case "/api/v1/games/9":
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Not found"})
+	case "/api/v1/game-sessions/start":
+		var body struct {
+			CountryCode string `json:"country_code"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		s.mu.Lock()
+		s.countries = append(s.countries, body.CountryCode)
+		s.mu.Unlock()
+		writeJSON(w, http.StatusCreated, map[string]any{"success": true, "data": map[string]any{"session_id": "s1", "game_url": "https://play.test/s1"}})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"message": "boom"})
+	}
+}
+
+func (s *availabilityServer) started() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.countries...)
+}
+
+func TestGamesAvailability(t *testing.T) {
+	ctx := context.Background()
+	games := flows.NewGamesFlow(newFakeAPI(t, &availabilityServer{}))
+
+	if resp := games.GetDetails(ctx, 9); resp.Success || resp.Error != flows.ErrGameNotFound.Error() {
+		t.Errorf("Expected a 404 to map to ErrGameNotFound, got %+v", resp)
+	}
+	if resp := games.Get(ctx, 7); !resp.Success || resp.Data["id"] != 7 {
+		t.Errorf("Expected Get to keep returning raw data, got %+v", resp)
+	}
+
+	tests := []struct {
+		gameID            int
+		currency, country string
+		reason            flows.UnavailableReason
+	}{
+		{7, "eur", "de", ""},
+		{7, "JPY", "", flows.ReasonCurrencyNotSupported},
+		{7, "EUR", "GB", flows.ReasonCountryRestricted},
+		{9, "EUR", "DE", flows.ReasonGameNotFound},
+		{11, "EUR", "DE", ""},
+		{11, "USD", "DE", flows.ReasonCurrencyNotSupported},
+		{11, "EUR", "GB", flows.ReasonCountryRestricted},
+	}
+	for _, tt := range tests {
+		a, err := games.IsAvailable(ctx, tt.gameID, tt.currency, tt.country, "")
+		if err != nil || a.Available != (tt.reason == "") || a.Reason != tt.reason {
+			t.Errorf("%+v: unexpected availability %+v, %v", tt, a, err)
+		}
+	}
+
+	// A failed lookup is an error, not a verdict
+	if a, err := games.IsAvailable(ctx, 10, "EUR", "DE", ""); err == nil || a.Available || a.Reason != "" {
+		t.Errorf("Expected a lookup error for game 10, got %+v, %v", a, err)
+	}
+}
+
+func TestSessionPreflight(t *testing.T) {
+	ctx := context.Background()
+	srv := &availabilityServer{}
+	api := newFakeAPI(t, srv)
+	sessions := flows.NewSessionsFlow(api)
+	sessions.SetPreflight(flows.NewGamesFlow(api))
+
+	refused := []struct {
+		resp   flows.SessionResponse
+		reason flows.UnavailableReason
+	}{
+		{sessions.Start(ctx, flows.StartSessionParams{GameID: 7, PlayerID: "p1", Currency: "EUR", CountryCode: "GB"}), flows.ReasonCountryRestricted},
+		{sessions.Start(ctx, flows.StartSessionParams{GameID: 9, PlayerID: "p1", Currency: "EUR", CountryCode: "DE"}), flows.ReasonGameNotFound},
+		{sessions.StartDemo(ctx, 7, flows.StartSessionParams{}), flows.ReasonDemoNotSupported},
+		{sessions.StartDemo(ctx, 8, flows.StartSessionParams{CountryCode: "US"}), flows.ReasonCountryRestricted},
+		{sessions.StartDemo(ctx, 11, flows.StartSessionParams{Currency: "USD"}), flows.ReasonCurrencyNotSupported},
+	}
+	for i, tt := range refused {
+		if tt.resp.Success || tt.resp.ErrorCode != flows.ErrorCodeGameUnavailable || tt.resp.Reason != tt.reason {
+			t.Errorf("Launch %d: expected %s, got %+v", i, tt.reason, tt.resp)
+		}
+	}
+	if started := srv.started(); len(started) != 0 {
+		t.Errorf("Refused launches reached the API: %v", started)
+	}
+
+	// The demo's default currency and country are not checked, and a
+	// failed lookup lets the API decide
+	for i, resp := range []flows.SessionResponse{
+		sessions.Start(ctx, flows.StartSessionParams{GameID: 7, PlayerID: "p1", Currency: "EUR", CountryCode: "DE"}),
+		sessions.StartDemo(ctx, 8, flows.StartSessionParams{}),
+		sessions.StartDemo(ctx, 11, flows.StartSessionParams{}),
+		sessions.Start(ctx, flows.StartSessionParams{GameID: 10, PlayerID: "p1", Currency: "EUR", CountryCode: "DE"}),
+	} {
+		if !resp.Success || resp.SessionID != "s1" {
+			t.Errorf("Launch %d: expected a session, got %+v", i, resp)
+		}
+	}
+	if started := srv.started(); strings.Join(started, ",") != "DE,US,US,DE" {
+		t.Errorf("Unexpected sessions started: %v", started)
+	}
+}
+
+func TestCatalogRevalidation(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu          sync.Mutex
+		titles      []string
+		conditional []string
+	)
+	etag := func() string { return `"` + strings.Join(titles[:min(2, len(titles))], "|") + `"` }
+	api := newFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		// The ETag only covers page 1, as a server that tags each response does
+		if page == 1 {
+			conditional = append(conditional, r.Header.Get("If-None-Match"))
+			if r.Header.Get("If-None-Match") == etag() {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag())
+		}
+		var data []map[string]any
+		for i := (page - 1) * 2; i < min(page*2, len(titles)); i++ {
+			data = append(data, map[string]any{"id": i + 1, "title": titles[i]})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"data": data,
+			"meta": map[string]any{"total": len(titles), "per_page": 2, "current_page": page, "last_page": (len(titles) + 1) / 2},
+		})
+	}))
+	load := func(t *testing.T, c *flows.Catalog) []flows.Game {
+		t.Helper()
+		if err := c.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		games, err := c.Games(ctx)
+		if err != nil {
+			t.Fatalf("Games failed: %v", err)
+		}
+		return games
+	}
+
+	t.Run("multi-page", func(t *testing.T) {
+		titles, conditional = []string{"A", "B", "C", "D", "E"}, nil
+		catalog := flows.NewGamesFlow(api).Catalog(flows.CatalogOptions{Params: flows.ListParams{PerPage: 2}})
+		load(t, catalog)
+
+		// Only page 3 changes; page 1 would still answer 304
+		mu.Lock()
+		titles[4] = "E2"
+		mu.Unlock()
+		games := load(t, catalog)
+		if len(games) != 5 || games[4].Title != "E2" {
+			t.Errorf("Expected the change on page 3, got %+v", games)
+		}
+		if strings.Join(conditional, ",") != "," {
+			t.Errorf("Expected unconditional loads, got If-None-Match %q", conditional)
+		}
+	})
+
+	t.Run("single page", func(t *testing.T) {
+		titles, conditional = []string{"A", "B"}, nil
+		catalog := flows.NewGamesFlow(api).Catalog(flows.CatalogOptions{Params: flows.ListParams{PerPage: 2}})
+		load(t, catalog)
+		load(t, catalog)
+		if stats := catalog.Stats(); stats.NotModified != 1 || conditional[1] != `"A|B"` {
+			t.Errorf("Expected a 304 revalidation, got %+v (If-None-Match %q)", stats, conditional)
+		}
+	})
+}
+
+// detailsServer serves the details of any game ID below 100, counting
+// requests per ID and the peak number in flight. While block is set,
+// requests wait for their context to end.
+type detailsServer struct {
+	mu       sync.Mutex
+	calls    map[int]int
+	inFlight int
+	peak     int
+	delay    time.Duration
+	block    bool
+	started  chan struct{}
+}
+
+func (s *detailsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/v1/games/"))
+	if err != nil || id >= 100 {
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "Not found"})
+		return
+	}
+	s.mu.Lock()
+	if s.calls == nil {
+		s.calls = make(map[int]int)
+	}
+	s.calls[id]++
+	s.inFlight++
+	s.peak = max(s.peak, s.inFlight)
+	block, started := s.block, s.started
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	if block {
+		started <- struct{}{}
+		<-r.Context().Done()
+		return
+	}
+	time.Sleep(s.delay)
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"id": id, "title": "Game " + strconv.Itoa(id)}})
+}
+
+func (s *detailsServer) callsFor(id int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[id]
+}
+
+func (s *detailsServer) peakInFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peak
+}
+
+func TestGetMany(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("duplicates", func(t *testing.T) {
+		srv := &detailsServer{}
+		resp := flows.NewGamesFlow(newFakeAPI(t, srv)).GetMany(ctx, []int{1, 2, 1, 3, 2})
+		if !resp.Success || len(resp.Games) != 3 || resp.Errors != nil {
+			t.Fatalf("Expected 3 games, got %+v", resp)
+		}
+		for _, id := range []int{1, 2, 3} {
+			if n := srv.callsFor(id); n != 1 {
+				t.Errorf("Game %d fetched %d times", id, n)
+			}
+		}
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		srv := &detailsServer{delay: 10 * time.Millisecond}
+		games := flows.NewGamesFlow(newFakeAPI(t, srv))
+		games.SetConcurrency(2)
+		resp := games.GetMany(ctx, []int{1, 2, 3, 4, 5, 6})
+		if peak := srv.peakInFlight(); !resp.Success || len(resp.Games) != 6 || peak != 2 {
+			t.Errorf("Expected 6 games with 2 lookups at a time, got %d games, peak %d", len(resp.Games), peak)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		srv := &detailsServer{block: true, started: make(chan struct{}, 4)}
+		games := flows.NewGamesFlow(newFakeAPI(t, srv))
+		games.SetConcurrency(1)
+		ctx, cancel := context.WithCancel(ctx)
+		go func() {
+			<-srv.started
+			cancel()
+		}()
+
+		resp := games.GetMany(ctx, []int{1, 2, 3, 4})
+		if resp.Success || len(resp.Games) != 0 || len(resp.Errors) != 4 {
+			t.Fatalf("Expected every ID to fail, got %+v", resp)
+		}
+		for id, msg := range resp.Errors {
+			if !strings.Contains(msg, context.Canceled.Error()) {
+				t.Errorf("Game %d: expected a cancellation, got %q", id, msg)
+			}
+		}
+	})
+
+	t.Run("warm catalog", func(t *testing.T) {
+		srv := &detailsServer{}
+		games := flows.NewGamesFlow(newFakeAPI(t, srv))
+		catalog := games.Catalog(flows.CatalogOptions{
+			Source: func(ctx context.Context, etag string) ([]flows.Game, string, error) {
+				return []flows.Game{{ID: 1}, {ID: 2}}, "", nil
+			},
+		})
+		if _, err := catalog.Games(ctx); err != nil {
+			t.Fatalf("Games failed: %v", err)
+		}
+		games.SetCatalog(catalog)
+
+		// An ID the catalog does not list is not looked up
+		resp := games.GetMany(ctx, []int{1, 99})
+		if resp.Games[1] == nil || resp.Errors[99] != flows.ErrGameNotFound.Error() || srv.callsFor(99) != 0 {
+			t.Errorf("Expected game 1 and ErrGameNotFound for 99, got %+v", resp)
+		}
+
+		// Fetched details are served from the cache afterwards
+		if resp := games.GetMany(ctx, []int{1}); resp.Games[1] == nil || srv.callsFor(1) != 1 {
+			t.Errorf("Expected game 1 from the cache, got %+v", resp)
+		}
+		if stats := catalog.Stats(); stats.DetailHits != 1 {
+			t.Errorf("Expected a detail hit, got %+v", stats)
+		}
+	})
+}
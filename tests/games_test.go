@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+)
+
+func TestDecodeGameDetails(t *testing.T) {
+	body := `{"data": {
+		"id": 7, "title": "Sweet Bonanza", "rtp": 96.48, "volatility": "high",
+		"min_bet": 0.2, "max_bet": 125, "currencies": ["USD", "EUR"],
+		"restricted_countries": ["GB"], "lines": 20, "features": ["tumble", "free_spins"],
+		"release_date": "2019-06-27", "thumbnails": {"small": "s.png"}, "has_demo": true,
+		"provider": {"id": 3, "name": "Pragmatic Play", "slug": "pragmatic"}
+	}}`
+	game, err := flows.DecodeGameDetails([]byte(body))
+	if err != nil {
+		t.Fatalf("DecodeGameDetails failed: %v", err)
+	}
+	if game.ID != 7 || game.RTP != 96.48 || game.Provider.Name != "Pragmatic Play" || game.Thumbnails["small"] != "s.png" {
+		t.Errorf("Unexpected details: %+v", game)
+	}
+	if game.ReleaseDate.Year() != 2019 || !game.HasDemo || len(game.Features) != 2 {
+		t.Errorf("Unexpected details: %+v", game)
+	}
+	if !game.SupportsCurrency("EUR") || game.SupportsCurrency("JPY") || !game.RestrictedIn("GB") {
+		t.Error("Unexpected currency or country checks")
+	}
+
+	invalid := []string{
+		`not json`,
+		`{"data": {"title": "No ID"}}`,
+		`{"id": 7}`,
+		`{"id": "7", "title": "String ID"}`,
+		`{"id": 7, "title": "Bad Date", "release_date": "soon"}`,
+	}
+	for _, body := range invalid {
+		if _, err := flows.DecodeGameDetails([]byte(body)); !errors.Is(err, flows.ErrInvalidGameDetails) {
+			t.Errorf("Expected ErrInvalidGameDetails for %s, got %v", body, err)
+		}
+	}
+}
+
+func TestProducerNavigation(t *testing.T) {
+	producers := []flows.Producer{
+		{ID: 1, Name: "Pragmatic Play", Slug: "pragmatic", Provider: "Direct"},
+		{ID: 2, Name: "NetEnt", Provider: "Evolution Group"},
+		{ID: 3, Name: "Evolution", Provider: "Evolution Group"},
+		{ID: 4, Name: "Retired Studio", Provider: "Direct"},
+	}
+	games := []flows.Game{
+		{ID: 1, Producer: "Pragmatic Play"},
+		{ID: 2, Producer: "pragmatic"},
+		{ID: 3, Producer: "NetEnt"},
+		{ID: 4, Producer: "Evolution"},
+		{ID: 5, Producer: "Evolution"},
+		{ID: 6, Producer: "Evolution"},
+	}
+
+	counted := flows.CountGames(producers, games)
+	if counted[0].GamesCount != 2 || counted[2].GamesCount != 3 || counted[3].GamesCount != 0 {
+		t.Errorf("Unexpected counts: %+v", counted)
+	}
+
+	nav := flows.ProducerNav(counted, flows.NavOptions{Featured: []string{"netent"}})
+	if len(nav) != 3 {
+		t.Fatalf("Expected producers without games to be hidden, got %+v", nav)
+	}
+	if nav[0].Producer.ID != 2 || !nav[0].Featured || nav[1].Producer.ID != 3 || nav[2].Params.ProducerID != 1 {
+		t.Errorf("Unexpected navigation order: %+v", nav)
+	}
+
+	providers := flows.GroupByProvider(counted)
+	if len(providers) != 2 || providers[1].Name != "Evolution Group" || providers[1].GamesCount != 4 {
+		t.Errorf("Unexpected providers: %+v", providers)
+	}
+}
+
+func TestCheckAvailability(t *testing.T) {
+	game := &flows.GameDetails{
+		ID:                  7,
+		Title:               "Sweet Bonanza",
+		Currencies:          []string{"USD", "EUR"},
+		RestrictedCountries: []string{"GB"},
+		Devices:             []string{"desktop", "mobile"},
+	}
+	tests := []struct {
+		currency, country, device string
+		demo                      bool
+		reason                    flows.UnavailableReason
+	}{
+		{"usd", "de", "Mobile", false, ""},
+		{"", "", "", false, ""},
+		{"JPY", "DE", "mobile", false, flows.ReasonCurrencyNotSupported},
+		{"EUR", "gb", "mobile", false, flows.ReasonCountryRestricted},
+		{"EUR", "DE", "tv", false, flows.ReasonDeviceNotSupported},
+		{"EUR", "DE", "desktop", true, flows.ReasonDemoNotSupported},
+	}
+	for _, tt := range tests {
+		err := flows.CheckAvailability(game, tt.currency, tt.country, tt.device, tt.demo)
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tt, err)
+			}
+			continue
+		}
+		var unavailable *flows.UnavailableError
+		if !errors.As(err, &unavailable) || unavailable.Reason != tt.reason || unavailable.GameID != 7 {
+			t.Errorf("%+v: expected %s, got %v", tt, tt.reason, err)
+		}
+		if !errors.Is(err, flows.ErrGameUnavailable) {
+			t.Errorf("%+v: expected ErrGameUnavailable", tt)
+		}
+	}
+
+	a := flows.Availability{GameID: 9, Reason: flows.ReasonGameNotFound}
+	if err := a.Err(); !errors.Is(err, flows.ErrGameUnavailable) || err.Error() != "game 9 unavailable: game_not_found" {
+		t.Errorf("Unexpected availability error: %v", a.Err())
+	}
+}
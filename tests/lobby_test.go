@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/flows"
+	"github.com/iplaygamesai/sdk-wrapper-go/lobby"
+)
+
+func TestLobbyBuild(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	games := []flows.Game{
+		{ID: 1, Title: "Sweet Bonanza", Producer: "Pragmatic", Type: "slots", HasDemo: true},
+		{ID: 2, Title: "Gates of Olympus", Producer: "Pragmatic", Type: "slots", HasDemo: true},
+		{ID: 3, Title: "Lightning Roulette", Producer: "Evolution", Type: "live"},
+		{ID: 4, Title: "Starburst", Producer: "NetEnt", Type: "slots", HasDemo: true},
+		{ID: 5, Title: "Book of Dead", Producer: "Play'n GO", Type: "slots", HasDemo: true},
+	}
+	l := lobby.New(lobby.Options{
+		Sections: []lobby.Section{
+			lobby.FeaturedSection("featured", "Featured", 4, 9, 1),
+			lobby.NewSection("new", "New Games", 30*24*time.Hour, 10),
+			lobby.PopularSection("popular", "Popular", 2),
+			lobby.TypeSection("live", "Live Casino", "live", lobby.ByTitle, 0),
+			{ID: "all", Title: "All Games", Sort: lobby.ProducerThenTitle},
+		},
+		Hidden: []int{5},
+		Details: map[int]*flows.GameDetails{
+			1: {ReleaseDate: now.Add(-10 * 24 * time.Hour), Currencies: []string{"USD", "EUR"}},
+			2: {ReleaseDate: now.Add(-24 * time.Hour)},
+			3: {RestrictedCountries: []string{"gb"}},
+			4: {},
+		},
+		Popularity: map[int]float64{1: 10, 3: 50, 4: 5},
+		Now:        func() time.Time { return now },
+	})
+
+	model := l.Build(games, lobby.Viewer{Country: "gb", Currency: "eur"})
+	ids := func(s lobby.SectionModel) []int {
+		var out []int
+		for _, g := range s.Games {
+			out = append(out, g.ID)
+		}
+		return out
+	}
+
+	// The live section is empty in GB and dropped; Book of Dead is hidden
+	if len(model.Sections) != 4 {
+		t.Fatalf("Expected 4 sections, got %d", len(model.Sections))
+	}
+	if got := ids(model.Sections[0]); len(got) != 2 || got[0] != 4 || got[1] != 1 || !model.Sections[0].Games[0].Pinned {
+		t.Errorf("Unexpected featured games: %v", got)
+	}
+	if got := ids(model.Sections[1]); len(got) != 2 || got[0] != 2 {
+		t.Errorf("Expected newest first, got %v", got)
+	}
+	if s := model.Sections[2]; s.Total != 2 || s.Games[0].ID != 1 {
+		t.Errorf("Unexpected popular section: %+v", s)
+	}
+	if got := ids(model.Sections[3]); len(got) != 3 || got[0] != 4 || got[1] != 2 {
+		t.Errorf("Expected games grouped by producer, got %v", got)
+	}
+
+	// A currency the game does not support removes it everywhere
+	for _, s := range l.Build(games, lobby.Viewer{Currency: "JPY"}).Sections {
+		for _, g := range s.Games {
+			if g.ID == 1 {
+				t.Errorf("Sweet Bonanza should not be offered in JPY (section %s)", s.ID)
+			}
+		}
+	}
+
+	body, err := json.Marshal(model)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded struct {
+		Country  string `json:"country"`
+		Sections []struct {
+			ID    string `json:"id"`
+			Games []struct {
+				ID         int    `json:"id"`
+				ReleasedAt string `json:"released_at"`
+			} `json:"games"`
+		} `json:"sections"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded.Country != "GB" || decoded.Sections[1].Games[0].ReleasedAt == "" {
+		t.Errorf("Unexpected JSON model: %s", body)
+	}
+}
+
+func TestLobbyHidesGamesWithoutDetails(t *testing.T) {
+	games := []flows.Game{
+		{ID: 1, Title: "Sweet Bonanza"},
+		{ID: 2, Title: "Gates of Olympus"},
+	}
+	opts := lobby.Options{
+		Sections: []lobby.Section{{ID: "all", Title: "All Games"}},
+		Details:  map[int]*flows.GameDetails{1: {}},
+	}
+	count := func(l *lobby.Lobby, v lobby.Viewer) int {
+		s, _ := l.Section("all", games, v)
+		return len(s.Games)
+	}
+
+	// Game 2 might be restricted for the viewer, so it is left out
+	l := lobby.New(opts)
+	for _, v := range []lobby.Viewer{{Country: "GB"}, {Currency: "EUR"}} {
+		if n := count(l, v); n != 1 {
+			t.Errorf("Expected only the game with details for %+v, got %d", v, n)
+		}
+	}
+	if n := count(l, lobby.Viewer{}); n != 2 {
+		t.Errorf("Expected both games without viewer rules, got %d", n)
+	}
+
+	opts.ShowWithoutDetails = true
+	if n := count(lobby.New(opts), lobby.Viewer{Country: "GB", Currency: "EUR"}); n != 2 {
+		t.Errorf("Expected ShowWithoutDetails to offer both games, got %d", n)
+	}
+}
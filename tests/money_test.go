@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+)
+
+func TestMoneyExponents(t *testing.T) {
+	cases := map[string]int{"USD": 2, "JPY": 0, "KWD": 3, "BTC": 8}
+	for currency, want := range cases {
+		got, err := money.Exponent(currency)
+		if err != nil || got != want {
+			t.Errorf("Exponent(%s) = %d, %v; want %d", currency, got, err, want)
+		}
+	}
+
+	if _, err := money.New(100, "XYZ"); !errors.Is(err, money.ErrUnknownCurrency) {
+		t.Errorf("Expected ErrUnknownCurrency, got %v", err)
+	}
+	if err := money.Register("XYZ", 4); err != nil {
+		t.Fatalf("Failed to register currency: %v", err)
+	}
+	if m := money.MustNew(12345, "xyz"); m.String() != "1.2345 XYZ" {
+		t.Errorf("Expected '1.2345 XYZ', got '%s'", m)
+	}
+}
+
+func TestMoneyParseAndFormat(t *testing.T) {
+	cases := []struct {
+		in       string
+		currency string
+		minor    int64
+		decimal  string
+	}{
+		{"0.29", "USD", 29, "0.29"},
+		{"12.3", "EUR", 1230, "12.30"},
+		{"-0.05", "USD", -5, "-0.05"},
+		{"1500", "JPY", 1500, "1500"},
+		{"0.00012", "BTC", 12000, "0.00012000"},
+	}
+	for _, c := range cases {
+		m, err := money.Parse(c.in, c.currency)
+		if err != nil {
+			t.Fatalf("Parse(%q, %s) failed: %v", c.in, c.currency, err)
+		}
+		if m.Minor() != c.minor || m.Decimal() != c.decimal {
+			t.Errorf("Parse(%q, %s) = %d (%s); want %d (%s)", c.in, c.currency, m.Minor(), m.Decimal(), c.minor, c.decimal)
+		}
+	}
+
+	if _, err := money.Parse("1.5", "JPY"); !errors.Is(err, money.ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount for fractional JPY, got %v", err)
+	}
+	if m, _ := money.FromMajor(0.29, "USD"); m.Minor() != 29 {
+		t.Errorf("Expected 29 cents, got %d", m.Minor())
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := money.MustNew(1050, "USD")
+	b := money.MustNew(275, "USD")
+
+	sum, err := a.Add(b)
+	if err != nil || sum.Minor() != 1325 {
+		t.Errorf("Expected 1325, got %d (%v)", sum.Minor(), err)
+	}
+	diff, _ := b.Sub(a)
+	if !diff.IsNegative() || diff.Decimal() != "-7.75" {
+		t.Errorf("Expected -7.75, got %s", diff.Decimal())
+	}
+	if _, err := a.Add(money.MustNew(1, "EUR")); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := money.MustNew(1<<62, "USD").Mul(4); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+
+	// math.MinInt64 is out of range everywhere, so Neg never overflows
+	if _, err := money.New(math.MinInt64, "USD"); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Expected New to reject MinInt64, got %v", err)
+	}
+	if _, err := money.Parse("-92233720368547758.08", "USD"); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Expected Parse to reject MinInt64, got %v", err)
+	}
+	min := money.MustNew(-math.MaxInt64, "USD")
+	if _, err := min.Sub(money.MustNew(1, "USD")); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Expected Sub to reject MinInt64, got %v", err)
+	}
+	if _, err := money.MustNew(math.MinInt64/2, "USD").Mul(2); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Expected Mul to reject MinInt64, got %v", err)
+	}
+	if neg := min.Neg(); neg.Minor() != math.MaxInt64 {
+		t.Errorf("Expected MaxInt64, got %d", neg.Minor())
+	}
+}
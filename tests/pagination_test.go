@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/pagination"
+)
+
+// pagedSource serves numbered pages of three items and records the requests
+type pagedSource struct {
+	mu       sync.Mutex
+	pages    int
+	failOn   int
+	requests []int
+}
+
+func (s *pagedSource) fetch(ctx context.Context, req pagination.Request) (pagination.Page[int], error) {
+	s.mu.Lock()
+	s.requests = append(s.requests, req.Page)
+	s.mu.Unlock()
+
+	if req.Page == s.failOn {
+		return pagination.Page[int]{}, errors.New("page unavailable")
+	}
+	page := pagination.Page[int]{}
+	for i := 0; i < 3; i++ {
+		page.Items = append(page.Items, (req.Page-1)*3+i)
+	}
+	if req.Page < s.pages {
+		page.Next = &pagination.Request{Page: req.Page + 1}
+	}
+	return page, nil
+}
+
+func (s *pagedSource) fetched() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.requests...)
+}
+
+func TestPaginationAll(t *testing.T) {
+	ctx := context.Background()
+
+	src := &pagedSource{pages: 3}
+	items, err := pagination.Collect(pagination.All(ctx, pagination.Request{Page: 1}, src.fetch))
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(items) != 9 || items[0] != 0 || items[8] != 8 {
+		t.Errorf("Expected items 0..8, got %v", items)
+	}
+	if got := src.fetched(); len(got) != 3 {
+		t.Errorf("Expected 3 page requests, got %v", got)
+	}
+
+	// The next page is requested before the current one is consumed
+	src = &pagedSource{pages: 5}
+	for item := range pagination.All(ctx, pagination.Request{Page: 1}, src.fetch) {
+		if item == 0 {
+			for i := 0; i < 100 && len(src.fetched()) < 2; i++ {
+				time.Sleep(time.Millisecond)
+			}
+			if got := src.fetched(); len(got) != 2 || got[1] != 2 {
+				t.Errorf("Expected page 2 to be prefetched, got %v", got)
+			}
+		}
+		if item == 1 {
+			break
+		}
+	}
+	if got := src.fetched(); len(got) != 2 {
+		t.Errorf("Expected no requests after break, got %v", got)
+	}
+
+	// A failed page is reported once after the items before it
+	src = &pagedSource{pages: 5, failOn: 2}
+	items, err = pagination.Collect(pagination.All(ctx, pagination.Request{Page: 1}, src.fetch))
+	if err == nil || err.Error() != "page unavailable" {
+		t.Errorf("Expected page error, got %v", err)
+	}
+	if len(items) != 3 {
+		t.Errorf("Expected the 3 items of page 1, got %v", items)
+	}
+}
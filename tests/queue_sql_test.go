@@ -0,0 +1,390 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
+)
+
+// fakeDB is an in-process database/sql driver that understands the
+// statements SQLQueue and SQLDeadLetters send. Placeholders may be ? or
+// $n; $n must be numbered in order.
+type fakeDB struct {
+	mu      sync.Mutex
+	tables  map[string][]map[string]driver.Value
+	dollars bool
+}
+
+func newFakeDB() (*fakeDB, *sql.DB) {
+	f := &fakeDB{tables: make(map[string][]map[string]driver.Value)}
+	return f, sql.OpenDB(f)
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                            { return f }
+func (f *fakeDB) Open(string) (driver.Conn, error)                 { return fakeConn{f}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("no transactions") }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	n, _, err := s.db.run(s.query, args)
+	return driver.RowsAffected(n), err
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	_, rows, err := s.db.run(s.query, args)
+	return rows, err
+}
+
+var (
+	dollarParam  = regexp.MustCompile(`\$(\d+)`)
+	createTable  = regexp.MustCompile(`^CREATE TABLE IF NOT EXISTS (\w+)`)
+	insertInto   = regexp.MustCompile(`^INSERT INTO (\w+) \(([^)]+)\) VALUES`)
+	selectFrom   = regexp.MustCompile(`^SELECT ([\w, ]+) FROM (\w+)(?: WHERE (.+?))?(?: ORDER BY (\w+))?(?: LIMIT (\d+))?$`)
+	updateSet    = regexp.MustCompile(`^UPDATE (\w+) SET (.+?) WHERE (.+)$`)
+	deleteFrom   = regexp.MustCompile(`^DELETE FROM (\w+) WHERE (.+)$`)
+	availableNow = "available_at <= ? AND (reserved_until IS NULL OR reserved_until < ?)"
+	unreserved   = "id = ? AND (reserved_until IS NULL OR reserved_until < ?)"
+)
+
+func (f *fakeDB) run(query string, args []driver.Value) (int64, *fakeRows, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if m := dollarParam.FindAllStringSubmatch(query, -1); len(m) > 0 {
+		f.dollars = true
+		for i, p := range m {
+			if p[1] != strconv.Itoa(i+1) {
+				return 0, nil, fmt.Errorf("placeholder %s out of order", p[0])
+			}
+		}
+		query = dollarParam.ReplaceAllString(query, "?")
+	}
+	query = strings.Join(strings.Fields(query), " ")
+	if n := strings.Count(query, "?"); n != len(args) {
+		return 0, nil, fmt.Errorf("%d placeholders, %d args", n, len(args))
+	}
+
+	switch {
+	case createTable.MatchString(query):
+		name := createTable.FindStringSubmatch(query)[1]
+		if f.tables[name] == nil {
+			f.tables[name] = []map[string]driver.Value{}
+		}
+		return 0, nil, nil
+
+	case insertInto.MatchString(query):
+		m := insertInto.FindStringSubmatch(query)
+		row := make(map[string]driver.Value)
+		for i, col := range strings.Split(m[2], ", ") {
+			row[col] = args[i]
+		}
+		for _, r := range f.tables[m[1]] {
+			if r["id"] == row["id"] {
+				return 0, nil, errors.New("duplicate primary key")
+			}
+		}
+		f.tables[m[1]] = append(f.tables[m[1]], row)
+		return 1, nil, nil
+
+	case selectFrom.MatchString(query):
+		m := selectFrom.FindStringSubmatch(query)
+		match, err := where(m[3], args)
+		if err != nil {
+			return 0, nil, err
+		}
+		var rows []map[string]driver.Value
+		for _, r := range f.tables[m[2]] {
+			if match(r) {
+				rows = append(rows, r)
+			}
+		}
+		if m[4] != "" {
+			sort.SliceStable(rows, func(i, j int) bool {
+				return rows[i][m[4]].(time.Time).Before(rows[j][m[4]].(time.Time))
+			})
+		}
+		if limit, _ := strconv.Atoi(m[5]); limit > 0 && len(rows) > limit {
+			rows = rows[:limit]
+		}
+		result := &fakeRows{cols: strings.Split(m[1], ", ")}
+		for _, r := range rows {
+			values := make([]driver.Value, len(result.cols))
+			for i, col := range result.cols {
+				values[i] = r[col]
+			}
+			result.rows = append(result.rows, values)
+		}
+		return int64(len(rows)), result, nil
+
+	case updateSet.MatchString(query):
+		m := updateSet.FindStringSubmatch(query)
+		sets := strings.Split(m[2], ", ")
+		values := make(map[string]driver.Value)
+		i := 0
+		for _, set := range sets {
+			col, value, _ := strings.Cut(set, " = ")
+			if value == "NULL" {
+				values[col] = nil
+				continue
+			}
+			values[col] = args[i]
+			i++
+		}
+		match, err := where(m[3], args[i:])
+		if err != nil {
+			return 0, nil, err
+		}
+		var n int64
+		for _, r := range f.tables[m[1]] {
+			if match(r) {
+				for col, v := range values {
+					r[col] = v
+				}
+				n++
+			}
+		}
+		return n, nil, nil
+
+	case deleteFrom.MatchString(query):
+		m := deleteFrom.FindStringSubmatch(query)
+		match, err := where(m[2], args)
+		if err != nil {
+			return 0, nil, err
+		}
+		kept := f.tables[m[1]][:0]
+		var n int64
+		for _, r := range f.tables[m[1]] {
+			if match(r) {
+				n++
+			} else {
+				kept = append(kept, r)
+			}
+		}
+		f.tables[m[1]] = kept
+		return n, nil, nil
+	}
+	return 0, nil, fmt.Errorf("unsupported statement: %s", query)
+}
+
+// where compiles the WHERE clauses the SQL stores use
+func where(clause string, args []driver.Value) (func(map[string]driver.Value) bool, error) {
+	before := func(v driver.Value, t driver.Value) bool {
+		return v != nil && v.(time.Time).Before(t.(time.Time))
+	}
+	switch clause {
+	case "":
+		return func(map[string]driver.Value) bool { return true }, nil
+	case "id = ?":
+		return func(r map[string]driver.Value) bool { return r["id"] == args[0] }, nil
+	case availableNow:
+		return func(r map[string]driver.Value) bool {
+			return !args[0].(time.Time).Before(r["available_at"].(time.Time)) &&
+				(r["reserved_until"] == nil || before(r["reserved_until"], args[1]))
+		}, nil
+	case unreserved:
+		return func(r map[string]driver.Value) bool {
+			return r["id"] == args[0] && (r["reserved_until"] == nil || before(r["reserved_until"], args[1]))
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported where clause: %s", clause)
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLQueue(t *testing.T) {
+	ctx := context.Background()
+	fake, db := newFakeDB()
+	defer db.Close()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	queue := webhooks.NewSQLQueue(db, webhooks.SQLOptions{
+		DollarPlaceholders: true,
+		PollInterval:       time.Millisecond,
+		Visibility:         time.Minute,
+		Now:                func() time.Time { return now },
+	})
+	if err := queue.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	queue.Enqueue(ctx, webhooks.Job{ID: "a", Type: "reward", Payload: []byte(`{"a":1}`), EnqueuedAt: now, AvailableAt: now})
+	queue.Enqueue(ctx, webhooks.Job{ID: "b", Type: "reward", Payload: []byte(`{"b":1}`), EnqueuedAt: now, AvailableAt: now.Add(time.Hour)})
+	if err := queue.Enqueue(ctx, webhooks.Job{ID: "a", EnqueuedAt: now, AvailableAt: now}); err == nil {
+		t.Error("Expected a duplicate job ID to fail")
+	}
+	if !fake.dollars {
+		t.Error("Expected $n placeholders")
+	}
+
+	dequeue := func() (webhooks.Job, error) {
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		return queue.Dequeue(ctx)
+	}
+
+	job, err := dequeue()
+	if err != nil || job.ID != "a" || string(job.Payload) != `{"a":1}` || !job.AvailableAt.Equal(now) {
+		t.Fatalf("Expected job a, got %+v (%v)", job, err)
+	}
+	// a is reserved and b is not available yet
+	if _, err := dequeue(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected no job while a is reserved, got %v", err)
+	}
+
+	// A crashed worker's reservation expires after the visibility timeout
+	now = now.Add(2 * time.Minute)
+	if job, err = dequeue(); err != nil || job.ID != "a" {
+		t.Fatalf("Expected a to be claimed again, got %+v (%v)", job, err)
+	}
+
+	job.Attempts, job.LastError = 1, "boom"
+	if err := queue.Nack(ctx, job, now.Add(10*time.Minute)); err != nil {
+		t.Fatalf("Nack failed: %v", err)
+	}
+	if _, err := dequeue(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a nacked job to wait for its retry time, got %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	first, _ := dequeue()
+	second, _ := dequeue()
+	if first.ID != "a" || first.Attempts != 1 || first.LastError != "boom" || second.ID != "b" {
+		t.Errorf("Expected retried a then b, got %+v and %+v", first, second)
+	}
+	queue.Ack(ctx, "a")
+	queue.Ack(ctx, "b")
+	if n := len(fake.tables["webhook_jobs"]); n != 0 {
+		t.Errorf("Expected acked jobs to be deleted, %d left", n)
+	}
+}
+
+func TestSQLDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	fake, db := newFakeDB()
+	defer db.Close()
+
+	dead := webhooks.NewSQLDeadLetters(db, webhooks.SQLOptions{DeadLettersTable: "dead"})
+	if err := dead.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"c", "a", "b"} {
+		job := webhooks.Job{ID: id, Type: "reward", Payload: []byte("{}"), Attempts: 5, LastError: "boom", EnqueuedAt: now}
+		if err := dead.Put(ctx, webhooks.DeadLetter{Job: job, FailedAt: now.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if fake.dollars {
+		t.Error("Expected ? placeholders")
+	}
+
+	dl, ok, err := dead.Get(ctx, "a")
+	if err != nil || !ok || dl.Job.Attempts != 5 || dl.Job.LastError != "boom" || !dl.FailedAt.Equal(now.Add(time.Second)) {
+		t.Errorf("Unexpected dead letter: %+v, %v, %v", dl, ok, err)
+	}
+	if _, ok, err := dead.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Expected a missing dead letter, got %v, %v", ok, err)
+	}
+
+	letters, err := dead.List(ctx, 2)
+	if err != nil || len(letters) != 2 || letters[0].Job.ID != "c" || letters[1].Job.ID != "a" {
+		t.Errorf("Expected the two oldest dead letters, got %+v (%v)", letters, err)
+	}
+	dead.Delete(ctx, "c")
+	if letters, _ := dead.List(ctx, 0); len(letters) != 2 || letters[0].Job.ID != "a" {
+		t.Errorf("Expected a and b after delete, got %+v", letters)
+	}
+}
+
+func TestSQLQueueSetClockWhileDequeueing(t *testing.T) {
+	ctx := context.Background()
+	_, db := newFakeDB()
+	defer db.Close()
+	queue := webhooks.NewSQLQueue(db, webhooks.SQLOptions{PollInterval: time.Millisecond})
+	queue.Migrate(ctx)
+
+	// A job a day ahead is only claimed once the clock moves; run with -race
+	later := time.Now().Add(24 * time.Hour)
+	queue.Enqueue(ctx, webhooks.Job{ID: "a", EnqueuedAt: later, AvailableAt: later})
+	claimed := make(chan webhooks.Job, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		job, _ := queue.Dequeue(ctx)
+		claimed <- job
+	}()
+	time.Sleep(5 * time.Millisecond)
+	queue.SetClock(func() time.Time { return later })
+	if job := <-claimed; job.ID != "a" {
+		t.Errorf("Expected job a after moving the clock, got %+v", job)
+	}
+}
+
+func TestAsyncQueueUsesRouterClock(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	// Jobs are stamped with the router's clock, a day ahead of wall time
+	ahead := time.Now().Add(24 * time.Hour)
+	processed := make(chan struct{}, 1)
+	router := webhooks.NewRouter(handler, newTestWallet(nil), webhooks.RouterOptions{
+		Now: func() time.Time { return ahead },
+		Async: &webhooks.AsyncOptions{
+			Types: []string{"notification"},
+			Process: func(ctx context.Context, p *webhooks.Payload) error {
+				processed <- struct{}{}
+				return nil
+			},
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go router.Async().Run(ctx)
+
+	p, _ := handler.Parse(`{"type":"notification","player_id":"p1"}`)
+	router.Handle(ctx, p)
+	select {
+	case <-processed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Job stamped with the router clock was never dequeued")
+	}
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+	"github.com/iplaygamesai/sdk-wrapper-go/responsible"
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
+)
+
+func TestResponsibleGuardPolicies(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	guard := responsible.NewGuard(responsible.Options{
+		Policies: []responsible.Policy{
+			responsible.CoolingOff{},
+			&responsible.MaxStake{Limit: money.MustNew(500, "USD")},
+			responsible.DailyLossLimit(money.MustNew(1000, "USD")),
+			&responsible.RealityCheck{Limit: time.Hour},
+		},
+		BreakAfter: 15 * time.Minute,
+		Now:        func() time.Time { return now },
+	})
+
+	blockedBy := func(err error) string {
+		var b *responsible.BlockedError
+		if errors.As(err, &b) {
+			return b.Policy
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return ""
+	}
+
+	if p := blockedBy(guard.CheckBet(ctx, "p1", money.MustNew(600, "USD"), false)); p != "max_stake" {
+		t.Errorf("Expected max_stake, got %q", p)
+	}
+	if p := blockedBy(guard.CheckBet(ctx, "p1", money.MustNew(600, "USD"), true)); p != "" {
+		t.Errorf("Freespin bets are exempt from max stake, got %q", p)
+	}
+
+	// Lose 900, win 100 back: net loss 800 of 1000
+	guard.RecordSessionStart(ctx, "p1")
+	for i := 0; i < 2; i++ {
+		guard.RecordBet(ctx, "p1", money.MustNew(450, "USD"))
+	}
+	guard.RecordWin(ctx, "p1", money.MustNew(100, "USD"))
+	if p := blockedBy(guard.CheckBet(ctx, "p1", money.MustNew(200, "USD"), false)); p != "" {
+		t.Errorf("Bet within loss limit was blocked by %q", p)
+	}
+	if p := blockedBy(guard.CheckBet(ctx, "p1", money.MustNew(201, "USD"), false)); p != "daily_loss_limit" {
+		t.Errorf("Expected daily_loss_limit, got %q", p)
+	}
+	if p := blockedBy(guard.CheckBet(ctx, "p1", money.MustNew(201, "EUR"), false)); p != "" {
+		t.Errorf("Limits only apply in their currency, got %q", p)
+	}
+
+	// Currency codes from callers and custom requests match in any case
+	stake := responsible.Request{Kind: responsible.KindBet, PlayerID: "p1", Currency: "usd", Stake: 600, At: now}
+	if p := blockedBy((&responsible.MaxStake{Limit: money.MustNew(500, "USD")}).Check(ctx, stake, guard.Store())); p != "max_stake" {
+		t.Errorf("Expected max_stake for a lowercase currency, got %q", p)
+	}
+	stake.Stake = 201
+	if p := blockedBy(responsible.DailyLossLimit(money.MustNew(1000, "USD")).Check(ctx, stake, guard.Store())); p != "daily_loss_limit" {
+		t.Errorf("Expected daily_loss_limit for a lowercase currency, got %q", p)
+	}
+
+	// The rolling window forgets yesterday's losses
+	now = now.Add(25 * time.Hour)
+	if p := blockedBy(guard.CheckBet(ctx, "p1", money.MustNew(500, "USD"), false)); p != "" {
+		t.Errorf("Old losses should have expired, got %q", p)
+	}
+
+	// Continuous play past the reality check limit
+	for i := 0; i < 7; i++ {
+		guard.RecordBet(ctx, "p2", money.MustNew(1, "USD"))
+		now = now.Add(10 * time.Minute)
+	}
+	err := guard.CheckSession(ctx, "p2", "USD")
+	var b *responsible.BlockedError
+	if !errors.As(err, &b) || b.Policy != "reality_check" || !b.Until.Equal(now.Add(5*time.Minute)) {
+		t.Errorf("Expected reality_check until break, got %v", err)
+	}
+	now = now.Add(15 * time.Minute)
+	if p := blockedBy(guard.CheckSession(ctx, "p2", "USD")); p != "" {
+		t.Errorf("A break should reset play time, got %q", p)
+	}
+
+	guard.CoolOff(ctx, "p3", 24*time.Hour)
+	if p := blockedBy(guard.CheckSession(ctx, "p3", "USD")); p != "cooling_off" {
+		t.Errorf("Expected cooling_off, got %q", p)
+	}
+	guard.SelfExclude(ctx, "p3")
+	now = now.Add(48 * time.Hour)
+	if p := blockedBy(guard.CheckSession(ctx, "p3", "USD")); p != "self_exclusion" {
+		t.Errorf("Expected self_exclusion, got %q", p)
+	}
+	guard.Lift(ctx, "p3")
+	if p := blockedBy(guard.CheckSession(ctx, "p3", "USD")); p != "" {
+		t.Errorf("Lifted exclusion still blocks: %q", p)
+	}
+}
+
+func TestRouterResponsibleGuard(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	guard := responsible.NewGuard(responsible.Options{
+		Policies: []responsible.Policy{responsible.DailyLossLimit(money.MustNew(300, "USD"))},
+	})
+	router := webhooks.NewRouter(handler, newTestWallet(map[string]int64{"p1": 1000}), webhooks.RouterOptions{Guard: guard})
+
+	handle := func(body string) map[string]interface{} {
+		p, err := handler.Parse(body)
+		if err != nil {
+			t.Fatalf("Failed to parse payload: %v", err)
+		}
+		return router.Handle(context.Background(), p)
+	}
+
+	handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":200,"round_id":"r1"}`)
+	resp := handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":2,"amount":200,"round_id":"r2"}`)
+	if resp["error_code"] != webhooks.CodePlayBlocked || resp["policy"] != "daily_loss_limit" || resp["balance"] != int64(800) {
+		t.Fatalf("Expected blocked bet with balance, got %v", resp)
+	}
+
+	// Rolling back the first bet frees the limit again
+	handle(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":3,"reference_transaction_id":1}`)
+	resp = handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":2,"amount":200,"round_id":"r2"}`)
+	if resp["status"] != "success" || resp["balance"] != int64(800) {
+		t.Errorf("Expected bet to be accepted after rollback, got %v", resp)
+	}
+}
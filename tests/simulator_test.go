@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks/simulator"
+)
+
+func TestSimulatorScenariosAgainstRouter(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	wallet := newTestWallet(map[string]int64{"p1": 100000})
+	router := webhooks.NewRouter(handler, wallet, webhooks.RouterOptions{})
+
+	sim := simulator.New(simulator.Options{Secret: webhookSecret, Handler: router})
+	ctx := context.Background()
+
+	scenarios := []simulator.Scenario{
+		sim.RoundTrip("p1", "USD", 500, 1200),
+		sim.Duplicates("p1", "USD", 300, 100),
+		sim.OutOfOrder("p1", "USD", 700),
+		sim.Freespins("p1", "USD", "fs_1", 3, 50),
+	}
+	for _, sc := range scenarios {
+		report, err := sim.Run(ctx, sc)
+		if err != nil {
+			t.Fatalf("Scenario %s failed to run: %v", sc.Name, err)
+		}
+		if !report.Passed() {
+			t.Errorf("Scenario failed:\n%s", report)
+		}
+	}
+}
+
+func TestSimulatorOutOfOrderCatchesLateDebit(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	// An endpoint that ignores rollbacks and debits every bet
+	balance := int64(10000)
+	naive := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		p, err := handler.ParseBytes(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if p.IsBet() && p.Amount != nil {
+			balance -= *p.Amount
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"status": "success", "balance": balance, "currency": p.Currency})
+	})
+
+	sim := simulator.New(simulator.Options{Secret: webhookSecret, Handler: naive})
+	report, err := sim.Run(context.Background(), sim.OutOfOrder("p1", "USD", 700))
+	if err != nil {
+		t.Fatalf("Scenario failed to run: %v", err)
+	}
+	if report.Passed() {
+		t.Fatal("Expected a wallet that debits the late bet to fail")
+	}
+	for _, step := range report.Steps {
+		if step.Step.Name == "late bet" && step.Passed() {
+			t.Errorf("Expected the late bet step to fail:\n%s", report)
+		}
+	}
+}
+
+func TestSimulatorSignsLikeHandler(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	sim := simulator.New(simulator.Options{Secret: webhookSecret})
+
+	body := sim.Bet("p1", "USD", 100, "").Body()
+	if !handler.Verify(string(body), sim.Sign(body)) {
+		t.Error("Simulator signature should verify")
+	}
+
+	router := webhooks.NewRouter(handler, newTestWallet(nil), webhooks.RouterOptions{})
+	sim = simulator.New(simulator.Options{Secret: "wrong_secret", Handler: router})
+	res, err := sim.Send(context.Background(), sim.BalanceCheck("p1", "USD"))
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a bad signature, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,1038 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
+)
+
+func TestWebhookTypedEvents(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+
+	event, err := handler.ParseEvent(`{"type":"bet","player_id":"player_456","currency":"USD","amount":1000,"transaction_id":12345,"round_id":"r1"}`)
+	if err != nil {
+		t.Fatalf("Failed to parse event: %v", err)
+	}
+
+	bet, ok := event.(*webhooks.BetEvent)
+	if !ok {
+		t.Fatalf("Expected *BetEvent, got %T", event)
+	}
+	if bet.TransactionID != 12345 || bet.Amount != 1000 || bet.RoundID != "r1" {
+		t.Errorf("Unexpected bet event: %+v", bet)
+	}
+	if bet.Common().PlayerID != "player_456" {
+		t.Errorf("Expected player_id 'player_456', got '%s'", bet.PlayerID)
+	}
+	if bet.Freespin != nil {
+		t.Error("Regular bet should not carry freespin details")
+	}
+}
+
+func TestWebhookTypedEventsRequireFields(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+
+	_, err := handler.ParseEvent(`{"type":"win","player_id":"player_456","currency":"USD","transaction_id":1}`)
+	var fieldErr *webhooks.FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("Expected FieldError, got %v", err)
+	}
+	if fieldErr.Field != "amount" {
+		t.Errorf("Expected missing amount, got %s", fieldErr.Field)
+	}
+
+	_, err = handler.ParseEvent(`{"type":"jackpot","player_id":"player_456"}`)
+	if !errors.Is(err, webhooks.ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+}
+
+func TestWebhookValidation(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	body := `{"type":"bet","currency":"usdd","amount":-5,"timestamp":"yesterday"}`
+
+	// Lenient parsing accepts the payload; Validate reports every problem
+	p, err := handler.Parse(body)
+	if err != nil {
+		t.Fatalf("Lenient parse should succeed, got %v", err)
+	}
+	var verr *webhooks.ValidationError
+	if !errors.As(p.Validate(), &verr) {
+		t.Fatalf("Expected ValidationError, got %v", p.Validate())
+	}
+	want := []string{"player_id", "currency", "timestamp", "transaction_id", "amount"}
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("Expected %d field errors, got %v", len(want), verr)
+	}
+	for i, field := range want {
+		if verr.Fields[i].Field != field {
+			t.Errorf("Field %d: expected %s, got %s", i, field, verr.Fields[i].Field)
+		}
+	}
+	if f := verr.Field("amount"); f == nil || f.Reason != "must not be negative" {
+		t.Errorf("Unexpected amount error: %v", f)
+	}
+
+	handler.SetValidationMode(webhooks.Strict)
+	if _, err := handler.Parse(body); !errors.As(err, &verr) {
+		t.Errorf("Strict parse should fail with ValidationError, got %v", err)
+	}
+	ok := `{"type":"win","player_id":"p1","currency":"EUR","transaction_id":7,"amount":0,"timestamp":"2024-01-15T10:30:00Z"}`
+	if _, err := handler.Parse(ok); err != nil {
+		t.Errorf("Strict parse of valid win failed: %v", err)
+	}
+	if _, err := handler.Parse(`{"type":"notification","message":"hi"}`); err != nil {
+		t.Errorf("Unknown types should only get format checks, got %v", err)
+	}
+	if _, err := handler.Parse(`{"player_id":"p1"}`); err == nil {
+		t.Error("Strict parse should require a type")
+	}
+}
+
+func TestWebhookVerifyRequest(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	body := `{"type":"balance_check", "player_id":"p1", "currency":"USD"}`
+	sig := handler.Sign(body)
+
+	if !handler.VerifyBytes([]byte(body), strings.ToUpper(sig)) || !handler.VerifyBytes([]byte(body), "sha256="+sig) {
+		t.Error("Uppercase and prefixed hex signatures should verify")
+	}
+	// Re-serialising the JSON changes the bytes and must not verify
+	if handler.Verify(`{"type":"balance_check","player_id":"p1","currency":"USD"}`, sig) {
+		t.Error("Re-serialised body should not verify")
+	}
+
+	handler.SetSignatureHeaders("X-Hub-Signature", "X-Signature")
+	handler.SetSignatureEncoding(webhooks.EncodingBase64)
+	handler.SetMaxBodyBytes(256)
+	sig = handler.Sign(body)
+
+	newRequest := func(body, header, sig string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		if header != "" {
+			req.Header.Set(header, sig)
+		}
+		return req
+	}
+
+	req := newRequest(body, "X-Hub-Signature", sig)
+	raw, err := handler.VerifyRequest(req)
+	if err != nil || string(raw) != body {
+		t.Fatalf("VerifyRequest failed: %v", err)
+	}
+	if again, _ := io.ReadAll(req.Body); string(again) != body {
+		t.Error("Request body should be restored after verification")
+	}
+	if _, err := handler.VerifyRequest(newRequest(body, "X-Signature", sig)); err != nil {
+		t.Errorf("Fallback header should be accepted, got %v", err)
+	}
+	if _, err := handler.VerifyRequest(newRequest(body, "X-Hub-Signature", handler.Sign("other"))); !errors.Is(err, webhooks.ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+	if _, err := handler.VerifyRequest(newRequest(body, "", "")); !errors.Is(err, webhooks.ErrMissingSignature) {
+		t.Errorf("Expected ErrMissingSignature, got %v", err)
+	}
+	big := strings.Repeat(" ", 300) + body
+	if _, err := handler.VerifyRequest(newRequest(big, "X-Hub-Signature", handler.Sign(big))); !errors.Is(err, webhooks.ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge, got %v", err)
+	}
+
+	// The router picks up the handler's headers and encoding
+	router := webhooks.NewRouter(handler, newTestWallet(map[string]int64{"p1": 500}), webhooks.RouterOptions{})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newRequest(body, "X-Hub-Signature", sig))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"balance":500`) {
+		t.Errorf("Unexpected router response %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWebhookMoneyResponses(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+
+	response := handler.SuccessResponse(0.29, nil)
+	if response["balance"] != 29 {
+		t.Errorf("Balance should be 29 cents, got %v", response["balance"])
+	}
+
+	response = handler.SuccessResponseMoney(money.MustNew(1500, "JPY"), nil)
+	if response["balance"] != int64(1500) || response["currency"] != "JPY" {
+		t.Errorf("Unexpected JPY response: %v", response)
+	}
+
+	response = handler.InsufficientFundsResponseMoney(money.MustNew(5025, "USD"))
+	if response["error_code"] != "INSUFFICIENT_FUNDS" || response["balance"] != int64(5025) {
+		t.Errorf("Unexpected insufficient funds response: %v", response)
+	}
+
+	parsed, _ := handler.Parse(`{"type":"bet","player_id":"player_456","currency":"JPY","amount":300}`)
+	amount, err := parsed.AmountMoney()
+	if err != nil || amount.String() != "300 JPY" {
+		t.Errorf("Expected '300 JPY', got '%s' (%v)", amount, err)
+	}
+}
+
+func TestWebhookParseLosslessNumbers(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+
+	parsed, err := handler.Parse(`{"type":"bet","player_id":42,"currency":"USD","transaction_id":9007199254740993,"amount":"1050","round_id":7}`)
+	if err != nil {
+		t.Fatalf("Failed to parse payload: %v", err)
+	}
+	if parsed.TransactionID == nil || *parsed.TransactionID != 9007199254740993 {
+		t.Errorf("Expected exact transaction ID, got %v", parsed.TransactionID)
+	}
+	if parsed.Amount == nil || *parsed.Amount != 1050 {
+		t.Errorf("Expected string amount to parse as 1050, got %v", parsed.Amount)
+	}
+	if parsed.PlayerID != "42" || parsed.RoundID != "7" {
+		t.Errorf("Expected numeric IDs as strings, got %q and %q", parsed.PlayerID, parsed.RoundID)
+	}
+
+	// Whole numbers written as decimals still parse
+	for _, amount := range []string{`100.0`, `1e2`, `"100.00"`} {
+		p, err := handler.Parse(`{"type":"bet","amount":` + amount + `}`)
+		if err != nil || *p.Amount != 100 {
+			t.Errorf("Expected amount %s to parse as 100, got %v", amount, err)
+		}
+	}
+	if v, ok := parsed.GetFloat64("amount"); !ok || v != 1050 {
+		t.Errorf("Expected a raw float, got %v, %v", v, ok)
+	}
+
+	_, err = handler.Parse(`{"type":"bet","amount":10.5}`)
+	var parseErr *webhooks.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Field != "amount" {
+		t.Errorf("Expected ParseError for amount, got %v", err)
+	}
+
+	for _, body := range []string{
+		`{"type":"bet","amount":100} {"type":"bet","amount":100}`,
+		`{"type":"bet","amount":100} trailing`,
+	} {
+		if _, err := handler.Parse(body); err == nil {
+			t.Errorf("Expected trailing data to be rejected: %s", body)
+		}
+	}
+	if _, err := handler.Parse("{\"type\":\"bet\",\"amount\":100}\n"); err != nil {
+		t.Errorf("Expected trailing whitespace to be accepted, got %v", err)
+	}
+}
+
+func TestWebhookRoundTracker(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var stuck []string
+	tracker := webhooks.NewRoundTracker(webhooks.RoundTrackerOptions{
+		Timeout: time.Minute,
+		Now:     func() time.Time { return now },
+		OnStuck: func(r webhooks.Round, flag string) { stuck = append(stuck, r.ID+":"+flag) },
+	})
+
+	track := func(body string) webhooks.Round {
+		p, err := handler.Parse(body)
+		if err != nil {
+			t.Fatalf("Failed to parse payload: %v", err)
+		}
+		r, err := tracker.Track(p)
+		if err != nil {
+			t.Fatalf("Failed to track payload: %v", err)
+		}
+		return r
+	}
+
+	track(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":500,"round_id":"r1"}`)
+	track(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":500,"round_id":"r1"}`)
+	r := track(`{"type":"win","player_id":"p1","currency":"USD","transaction_id":2,"amount":1200,"round_id":"r1"}`)
+	if r.Status != webhooks.RoundSettled || r.Net() != 700 {
+		t.Errorf("Expected settled round with net 700, got %s with %d", r.Status, r.Net())
+	}
+
+	track(`{"type":"win","player_id":"p1","currency":"USD","transaction_id":3,"amount":100,"round_id":"r2"}`)
+	track(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":4,"amount":100,"round_id":"r3"}`)
+
+	now = now.Add(2 * time.Minute)
+	flagged := tracker.Check()
+	if len(flagged) != 1 || flagged[0].ID != "r3" {
+		t.Errorf("Expected r3 to time out, got %v", flagged)
+	}
+	if len(stuck) != 2 || stuck[0] != "r2:win_without_bet" || stuck[1] != "r3:timed_out" {
+		t.Errorf("Unexpected stuck notifications: %v", stuck)
+	}
+	if len(tracker.Stuck()) != 2 || len(tracker.Open()) != 1 {
+		t.Errorf("Expected 2 stuck and 1 open round")
+	}
+
+	// Rollbacks without an amount reverse the transaction they refer to
+	track(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":10,"amount":500,"round_id":"r4"}`)
+	track(`{"type":"win","player_id":"p1","currency":"USD","transaction_id":11,"amount":1200,"round_id":"r4"}`)
+	r = track(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":12,"reference_transaction_id":11,"round_id":"r4"}`)
+	if r.Net() != -500 || r.RollbackTotal != -1200 || r.Status != webhooks.RoundOpen || !r.Reversed(11) {
+		t.Errorf("Expected rolled back win to reopen the round with net -500, got %s with %d", r.Status, r.Net())
+	}
+	r = track(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":13,"reference_transaction_id":11,"round_id":"r4"}`)
+	if r.Net() != -500 {
+		t.Errorf("Expected a second rollback of the win to be ignored, got net %d", r.Net())
+	}
+	r = track(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":10,"round_id":"r4"}`)
+	if r.Net() != 0 || r.Transactions[len(r.Transactions)-1].ReversedID == nil {
+		t.Errorf("Expected rolled back bet to leave net 0, got %d", r.Net())
+	}
+	if r.Status != webhooks.RoundRolledBack || r.ClosedAt.IsZero() {
+		t.Errorf("Expected the round rolled back once every transaction is reversed, got %s", r.Status)
+	}
+
+	// Rolling back one of several bets leaves the round open
+	track(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":30,"amount":100,"round_id":"r6"}`)
+	track(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":31,"amount":200,"round_id":"r6"}`)
+	r = track(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":32,"reference_transaction_id":30,"round_id":"r6"}`)
+	if r.Status != webhooks.RoundOpen || !r.Reversed(30) || r.Reversed(31) || r.Net() != -200 {
+		t.Errorf("Expected r6 open with bet 30 reversed, got %s with net %d", r.Status, r.Net())
+	}
+	track(`{"type":"win","player_id":"p1","currency":"USD","transaction_id":33,"amount":500,"round_id":"r6"}`)
+	r = track(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":31,"round_id":"r6"}`)
+	if r.Status != webhooks.RoundSettled || r.Net() != 500 {
+		t.Errorf("Expected r6 settled by its remaining win, got %s with net %d", r.Status, r.Net())
+	}
+
+	p, _ := handler.Parse(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":20,"round_id":"r5"}`)
+	if _, err := tracker.Track(p); !errors.Is(err, webhooks.ErrUnresolvedRollback) {
+		t.Errorf("Expected ErrUnresolvedRollback, got %v", err)
+	}
+	if _, ok := tracker.Round("r5"); ok {
+		t.Error("Expected no round for an unresolved rollback")
+	}
+}
+
+// testWallet is a minimal in-memory Wallet for router tests
+type testWallet struct {
+	mu       sync.Mutex
+	balances map[string]int64
+}
+
+func newTestWallet(balances map[string]int64) *testWallet {
+	return &testWallet{balances: balances}
+}
+
+func (w *testWallet) Balance(ctx context.Context, playerID, currency string) (money.Money, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	bal, ok := w.balances[playerID]
+	if !ok {
+		return money.Money{}, webhooks.ErrPlayerNotFound
+	}
+	return money.New(bal, currency)
+}
+
+func (w *testWallet) Debit(ctx context.Context, playerID string, amount money.Money, transactionID int64) (money.Money, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	bal, ok := w.balances[playerID]
+	if !ok {
+		return money.Money{}, webhooks.ErrPlayerNotFound
+	}
+	if bal < amount.Minor() {
+		return money.Money{}, webhooks.ErrInsufficientFunds
+	}
+	w.balances[playerID] = bal - amount.Minor()
+	return money.New(w.balances[playerID], amount.Currency())
+}
+
+func (w *testWallet) Credit(ctx context.Context, playerID string, amount money.Money, transactionID int64) (money.Money, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	bal, ok := w.balances[playerID]
+	if !ok {
+		return money.Money{}, webhooks.ErrPlayerNotFound
+	}
+	w.balances[playerID] = bal + amount.Minor()
+	return money.New(w.balances[playerID], amount.Currency())
+}
+
+func TestWebhookRouterRollback(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	wallet := newTestWallet(map[string]int64{"p1": 10000})
+	router := webhooks.NewRouter(handler, wallet, webhooks.RouterOptions{WinWait: -1})
+	ctx := context.Background()
+
+	handle := func(body string) map[string]interface{} {
+		p, err := handler.Parse(body)
+		if err != nil {
+			t.Fatalf("Failed to parse payload: %v", err)
+		}
+		return router.Handle(ctx, p)
+	}
+
+	handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":1000,"round_id":"r1"}`)
+	handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":2,"amount":300,"round_id":"r1"}`)
+
+	// Explicit reference reverses exactly that bet, once
+	resp := handle(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":10,"reference_transaction_id":1}`)
+	if resp["status"] != "success" || resp["balance"] != int64(9700) {
+		t.Errorf("Expected balance 9700 after rollback, got %v", resp)
+	}
+	resp = handle(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":11,"reference_transaction_id":1}`)
+	if resp["already_processed"] != true || resp["balance"] != int64(9700) {
+		t.Errorf("Second rollback of the same bet should be a no-op, got %v", resp)
+	}
+
+	// Without a reference the open bet of the round is used
+	resp = handle(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":12,"round_id":"r1"}`)
+	if resp["balance"] != int64(10000) {
+		t.Errorf("Expected balance 10000 after round rollback, got %v", resp)
+	}
+
+	// Rollback before its bet tombstones the bet
+	resp = handle(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":13,"reference_transaction_id":5}`)
+	if resp["status"] != "success" {
+		t.Errorf("Rollback of unknown transaction should succeed, got %v", resp)
+	}
+	resp = handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":5,"amount":500,"round_id":"r2"}`)
+	if resp["error_code"] != webhooks.CodeTransactionRolledBack || resp["balance"] != int64(10000) {
+		t.Errorf("Tombstoned bet should be refused without debit, got %v", resp)
+	}
+
+	// Without a reference or a matching bet the rest of the round is refused
+	handle(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":14,"round_id":"r3"}`)
+	resp = handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":6,"amount":500,"round_id":"r3"}`)
+	if resp["error_code"] != webhooks.CodeTransactionRolledBack || resp["balance"] != int64(10000) {
+		t.Errorf("Late bet of a rolled back round should be refused, got %v", resp)
+	}
+	resp = handle(`{"type":"win","player_id":"p1","currency":"USD","transaction_id":7,"amount":900,"round_id":"r3"}`)
+	if resp["error_code"] != webhooks.CodeTransactionRolledBack || resp["balance"] != int64(10000) {
+		t.Errorf("Late win of a rolled back round should be refused, got %v", resp)
+	}
+}
+
+func TestWebhookRouterRollbackOtherPlayer(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	wallet := newTestWallet(map[string]int64{"p1": 10000, "p2": 10000})
+	router := webhooks.NewRouter(handler, wallet, webhooks.RouterOptions{WinWait: -1})
+	ctx := context.Background()
+
+	handle := func(body string) map[string]interface{} {
+		p, err := handler.Parse(body)
+		if err != nil {
+			t.Fatalf("Failed to parse payload: %v", err)
+		}
+		return router.Handle(ctx, p)
+	}
+
+	handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":1000,"round_id":"r1"}`)
+	handle(`{"type":"win","player_id":"p1","currency":"USD","transaction_id":2,"amount":3000,"round_id":"r1"}`)
+
+	// p2 naming p1's bet and win moves no money
+	handle(`{"type":"rollback","player_id":"p2","currency":"USD","transaction_id":10,"reference_transaction_id":1}`)
+	handle(`{"type":"rollback","player_id":"p2","currency":"USD","transaction_id":11,"reference_transaction_id":2}`)
+	handle(`{"type":"rollback","player_id":"p2","currency":"USD","transaction_id":1}`)
+	handle(`{"type":"rollback","player_id":"p2","currency":"USD","transaction_id":12,"round_id":"r1"}`)
+	// Nor does p1 in another currency
+	handle(`{"type":"rollback","player_id":"p1","currency":"EUR","transaction_id":13,"reference_transaction_id":1}`)
+	if wallet.balances["p1"] != 12000 || wallet.balances["p2"] != 10000 {
+		t.Errorf("Expected balances to be unchanged, got %v", wallet.balances)
+	}
+
+	// p1's own rollback still reverses the bet
+	resp := handle(`{"type":"rollback","player_id":"p1","currency":"USD","transaction_id":14,"reference_transaction_id":1}`)
+	if resp["status"] != "success" || resp["balance"] != int64(13000) {
+		t.Errorf("Expected p1's rollback to refund the bet, got %v", resp)
+	}
+
+	// p2's tombstones do not refuse p1's transactions
+	resp = handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":3,"amount":500,"round_id":"r1"}`)
+	if resp["status"] != "success" || resp["balance"] != int64(12500) {
+		t.Errorf("Expected p1's bet to be debited, got %v", resp)
+	}
+}
+
+func TestMemoryLedgerRetention(t *testing.T) {
+	ctx := context.Background()
+	ledger := webhooks.NewMemoryLedger()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	usd := func(minor int64) money.Money { return money.MustNew(minor, "USD") }
+
+	ledger.Put(ctx, webhooks.LedgerEntry{TransactionID: 1, Type: webhooks.TypeBet, PlayerID: "p1", RoundID: "r1", Amount: usd(100), At: now})
+	ledger.Put(ctx, webhooks.LedgerEntry{TransactionID: 2, Type: webhooks.TypeWin, PlayerID: "p1", RoundID: "r1", Amount: usd(300), At: now.Add(time.Second)})
+	ledger.Put(ctx, webhooks.LedgerEntry{TransactionID: 3, Type: webhooks.TypeBet, PlayerID: "p2", RoundID: "r1", Amount: usd(100), At: now})
+	ledger.Tombstone(ctx, webhooks.Tombstone{PlayerID: "p1", RoundID: "r2", RollbackID: 9, At: now})
+
+	round, _ := ledger.Round(ctx, "p1", "r1")
+	if len(round) != 2 || round[0].TransactionID != 1 || round[1].TransactionID != 2 {
+		t.Errorf("Expected p1's two entries of r1, got %+v", round)
+	}
+	if dead, _ := ledger.IsTombstoned(ctx, "p1", "r2", 42); !dead {
+		t.Error("Expected round r2 to be tombstoned for p1")
+	}
+	if dead, _ := ledger.IsTombstoned(ctx, "p2", "r2", 42); dead {
+		t.Error("Expected round r2 not to be tombstoned for p2")
+	}
+
+	// Writing a day later drops everything older than the retention
+	ledger.Put(ctx, webhooks.LedgerEntry{TransactionID: 4, Type: webhooks.TypeBet, PlayerID: "p1", RoundID: "r1", Amount: usd(100), At: now.Add(25 * time.Hour)})
+	if _, ok, _ := ledger.Get(ctx, webhooks.TypeBet, 1); ok {
+		t.Error("Expected expired entry to be dropped")
+	}
+	if round, _ := ledger.Round(ctx, "p1", "r1"); len(round) != 1 || round[0].TransactionID != 4 {
+		t.Errorf("Expected only the new entry in r1, got %+v", round)
+	}
+	if dead, _ := ledger.IsTombstoned(ctx, "p1", "r2", 42); dead {
+		t.Error("Expected expired tombstone to be dropped")
+	}
+	if n := ledger.Prune(now.Add(26 * time.Hour)); n != 1 {
+		t.Errorf("Expected Prune to remove 1 entry, got %d", n)
+	}
+}
+
+func TestWebhookRouterAsyncQueue(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	wallet := newTestWallet(map[string]int64{"p1": 1000})
+
+	var (
+		mu       sync.Mutex
+		notified int
+		dead     = make(chan webhooks.DeadLetter, 1)
+	)
+	router := webhooks.NewRouter(handler, wallet, webhooks.RouterOptions{
+		Async: &webhooks.AsyncOptions{
+			Types:       []string{webhooks.TypeReward, "notification", webhooks.TypeBet},
+			MaxAttempts: 2,
+			Backoff:     func(int) time.Duration { return time.Millisecond },
+			Process: func(ctx context.Context, p *webhooks.Payload) error {
+				mu.Lock()
+				defer mu.Unlock()
+				notified++
+				return errors.New("downstream unavailable")
+			},
+			OnDeadLetter: func(dl webhooks.DeadLetter) { dead <- dl },
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		router.Async().Run(ctx)
+		close(done)
+	}()
+
+	handle := func(body string) map[string]interface{} {
+		p, err := handler.Parse(body)
+		if err != nil {
+			t.Fatalf("Failed to parse payload: %v", err)
+		}
+		return router.Handle(context.Background(), p)
+	}
+
+	// Bets stay synchronous even when listed
+	resp := handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":100}`)
+	if resp["queued"] != nil || resp["balance"] != int64(900) {
+		t.Errorf("Bet should be processed synchronously, got %v", resp)
+	}
+
+	resp = handle(`{"type":"reward","player_id":"p1","currency":"USD","transaction_id":2,"amount":500,"reward_type":"tournament"}`)
+	if resp["queued"] != true {
+		t.Errorf("Reward should be queued, got %v", resp)
+	}
+
+	handle(`{"type":"notification","player_id":"p1","message":"hello"}`)
+	var dl webhooks.DeadLetter
+	select {
+	case dl = <-dead:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Notification was never dead-lettered")
+	}
+	if dl.Job.Type != "notification" || dl.Job.Attempts != 2 {
+		t.Errorf("Unexpected dead letter: %+v", dl.Job)
+	}
+
+	if bal, _ := wallet.Balance(context.Background(), "p1", "USD"); bal.Minor() != 1400 {
+		t.Errorf("Expected queued reward to be credited, balance %d", bal.Minor())
+	}
+
+	if err := router.Async().Replay(context.Background(), dl.Job.ID); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	select {
+	case <-dead:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Replayed notification was never processed")
+	}
+	mu.Lock()
+	if notified != 4 {
+		t.Errorf("Expected 4 processing attempts, got %d", notified)
+	}
+	mu.Unlock()
+
+	cancel()
+	<-done
+}
+
+func TestWebhookAuditLog(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := webhooks.OpenFileAuditLog(path, webhooks.FileAuditOptions{})
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	router := webhooks.NewRouter(handler, newTestWallet(map[string]int64{"p1": 1000}), webhooks.RouterOptions{Audit: audit})
+
+	send := func(body, signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		req.Header.Set("X-Signature", signature)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	bet := `{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":100}`
+	send(bet, handler.Sign(bet))
+	send(bet, "bad")
+	bal := `{"type":"balance_check","player_id":"p2","currency":"USD"}`
+	send(bal, handler.Sign(bal))
+	audit.Close()
+
+	data, _ := os.ReadFile(path)
+	n, head, err := webhooks.VerifyAuditLog(bytes.NewReader(data))
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 verified records, got %d, %v", n, err)
+	}
+	if strings.Contains(string(data), "Bearer secret") {
+		t.Error("Authorization header should be redacted")
+	}
+
+	// Reopening continues the chain
+	audit, err = webhooks.OpenFileAuditLog(path, webhooks.FileAuditOptions{})
+	if err != nil {
+		t.Fatalf("Failed to reopen audit log: %v", err)
+	}
+	if seq, h := audit.Head(); seq != 2 || h != head {
+		t.Errorf("Expected head (2, %s), got (%d, %s)", head, seq, h)
+	}
+	audit.Close()
+
+	var out bytes.Buffer
+	exported, err := webhooks.ExportAuditLog(&out, bytes.NewReader(data), webhooks.AuditFilter{PlayerID: "p1"})
+	if err != nil || exported != 1 || !strings.Contains(out.String(), `"response":{"balance":900`) {
+		t.Errorf("Unexpected export (%d, %v): %s", exported, err, out.String())
+	}
+
+	tampered := bytes.Replace(data, []byte(`"balance":900`), []byte(`"balance":9000`), 1)
+	_, _, err = webhooks.VerifyAuditLog(bytes.NewReader(tampered))
+	var chain *webhooks.AuditChainError
+	if !errors.As(err, &chain) || chain.Seq != 1 {
+		t.Errorf("Expected chain error at seq 1, got %v", err)
+	}
+	if _, err := webhooks.OpenFileAuditLog(writeTemp(t, tampered), webhooks.FileAuditOptions{}); !errors.Is(err, webhooks.ErrAuditChainBroken) {
+		t.Errorf("Expected tampered log to be refused, got %v", err)
+	}
+}
+
+func TestWebhookAuditLogRawBody(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	var buf bytes.Buffer
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := webhooks.OpenFileAuditLog(path, webhooks.FileAuditOptions{})
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	router := webhooks.NewRouter(handler, newTestWallet(map[string]int64{"p1": 1000}), webhooks.RouterOptions{Audit: audit})
+
+	// Latin-1 bytes are not valid UTF-8 and must be logged unchanged
+	body := "{\"type\":\"balance_check\",\"player_id\":\"p1\",\"currency\":\"USD\",\"note\":\"caf\xe9\"}"
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	req.Header.Set("X-Signature", handler.Sign(body))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	audit.Close()
+
+	data, _ := os.ReadFile(path)
+	if _, err := webhooks.ExportAuditLog(&buf, bytes.NewReader(data), webhooks.AuditFilter{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	var rec webhooks.AuditRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Failed to decode record: %v", err)
+	}
+	if string(rec.Entry.Body) != body {
+		t.Errorf("Expected the raw body back, got %q", rec.Entry.Body)
+	}
+}
+
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+type recordingBroker struct {
+	mu     sync.Mutex
+	topics []string
+}
+
+func (b *recordingBroker) Publish(ctx context.Context, topic string, body []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topics = append(b.topics, topic)
+	return nil
+}
+
+func TestWebhookEventBus(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	bus := webhooks.NewBus(webhooks.BusOptions{})
+
+	var (
+		mu       sync.Mutex
+		ids      []string
+		failures int
+	)
+	// A flaky subscriber still sees every message, in order
+	_, err := bus.Subscribe("crm", func(ctx context.Context, msg webhooks.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures < 2 {
+			failures++
+			return errors.New("crm unavailable")
+		}
+		ids = append(ids, msg.ID)
+		return nil
+	}, webhooks.SubscribeOptions{Backoff: func(int) time.Duration { return time.Millisecond }})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	var wins []webhooks.Message
+	bus.Subscribe("bonus", func(ctx context.Context, msg webhooks.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		wins = append(wins, msg)
+		return nil
+	}, webhooks.SubscribeOptions{Types: []string{webhooks.TypeWin}})
+
+	broker := &recordingBroker{}
+	bus.Forward("kafka", broker, nil, webhooks.SubscribeOptions{})
+
+	router := webhooks.NewRouter(handler, newTestWallet(map[string]int64{"p1": 1000}), webhooks.RouterOptions{Bus: bus})
+	for _, body := range []string{
+		`{"type":"balance_check","player_id":"p1","currency":"USD"}`,
+		`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":100,"round_id":"r1"}`,
+		`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":100,"round_id":"r1"}`,
+		`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":2,"amount":5000,"round_id":"r2"}`,
+		`{"type":"win","player_id":"p1","currency":"USD","transaction_id":3,"amount":250,"round_id":"r1"}`,
+	} {
+		p, err := handler.Parse(body)
+		if err != nil {
+			t.Fatalf("Failed to parse payload: %v", err)
+		}
+		router.Handle(context.Background(), p)
+	}
+
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Balance checks, duplicates and failed bets are not published
+	if len(ids) != 2 || ids[0] != "bet:1" || ids[1] != "win:3" {
+		t.Errorf("Unexpected CRM deliveries: %v", ids)
+	}
+	if len(wins) != 1 || wins[0].Balance != 1150 || wins[0].Raw["round_id"] != "r1" {
+		t.Errorf("Unexpected bonus deliveries: %+v", wins)
+	}
+	if len(broker.topics) != 2 || broker.topics[1] != "gamehub.webhooks.win" {
+		t.Errorf("Unexpected broker topics: %v", broker.topics)
+	}
+	if err := bus.Publish(context.Background(), wins[0]); !errors.Is(err, webhooks.ErrBusClosed) {
+		t.Errorf("Expected ErrBusClosed, got %v", err)
+	}
+}
+
+func TestWebhookEventBusBackpressure(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	p, _ := handler.Parse(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":100}`)
+	event, _ := p.Event()
+	msg := webhooks.Message{ID: "bet:1", Event: event}
+
+	release := make(chan struct{})
+	var dropped int
+	bus := webhooks.NewBus(webhooks.BusOptions{
+		OnError: func(string, webhooks.Message, error) { dropped++ },
+	})
+	bus.Subscribe("slow", func(ctx context.Context, msg webhooks.Message) error {
+		<-release
+		return nil
+	}, webhooks.SubscribeOptions{Buffer: 1, Overflow: webhooks.DropNewest})
+
+	// One message in flight, one buffered, the third is dropped
+	bus.Publish(context.Background(), msg)
+	time.Sleep(10 * time.Millisecond)
+	bus.Publish(context.Background(), msg)
+	if err := bus.Publish(context.Background(), msg); !errors.Is(err, webhooks.ErrBufferFull) || dropped != 1 {
+		t.Errorf("Expected ErrBufferFull, got %v", err)
+	}
+
+	blocking := webhooks.NewBus(webhooks.BusOptions{})
+	blocking.Subscribe("slow", func(ctx context.Context, msg webhooks.Message) error {
+		<-release
+		return nil
+	}, webhooks.SubscribeOptions{Buffer: 1})
+	blocking.Publish(context.Background(), msg)
+	time.Sleep(10 * time.Millisecond)
+	blocking.Publish(context.Background(), msg)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := blocking.Publish(ctx, msg); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Publish to block until the deadline, got %v", err)
+	}
+
+	close(release)
+	bus.Close(context.Background())
+	blocking.Close(context.Background())
+}
+
+func TestWebhookRouterPublishOutsidePlayerLock(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	release := make(chan struct{})
+	bus := webhooks.NewBus(webhooks.BusOptions{})
+	bus.Subscribe("slow", func(ctx context.Context, msg webhooks.Message) error {
+		<-release
+		return nil
+	}, webhooks.SubscribeOptions{Buffer: 1})
+	router := webhooks.NewRouter(handler, newTestWallet(map[string]int64{"p1": 1000}), webhooks.RouterOptions{Bus: bus})
+
+	handle := func(body string) map[string]interface{} {
+		p, err := handler.Parse(body)
+		if err != nil {
+			t.Fatalf("Failed to parse payload: %v", err)
+		}
+		return router.Handle(context.Background(), p)
+	}
+
+	// One bet in flight and one buffered; the third blocks in Publish
+	handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":100,"round_id":"r1"}`)
+	time.Sleep(10 * time.Millisecond)
+	handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":2,"amount":100,"round_id":"r2"}`)
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":3,"amount":100,"round_id":"r3"}`)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// The player's next webhook is not held up by the blocked publish
+	done := make(chan map[string]interface{})
+	go func() {
+		done <- handle(`{"type":"balance_check","player_id":"p1","currency":"USD"}`)
+	}()
+	select {
+	case resp := <-done:
+		if resp["balance"] != int64(700) {
+			t.Errorf("Expected balance 700, got %v", resp["balance"])
+		}
+	case <-time.After(time.Second):
+		t.Error("Balance check waited for a blocked publish")
+	}
+
+	close(release)
+	<-blocked
+	bus.Close(context.Background())
+}
+
+func TestWebhookEventBusCloseWhilePublishing(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	p, _ := handler.Parse(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":100}`)
+	event, _ := p.Event()
+	msg := webhooks.Message{ID: "bet:1", Event: event}
+
+	// The handler ignores its context, so only Close's deadline ends the wait
+	release := make(chan struct{})
+	defer close(release)
+	var mu sync.Mutex
+	var abandoned int
+	bus := webhooks.NewBus(webhooks.BusOptions{
+		OnError: func(string, webhooks.Message, error) { mu.Lock(); abandoned++; mu.Unlock() },
+	})
+	bus.Subscribe("stuck", func(ctx context.Context, msg webhooks.Message) error {
+		<-release
+		return nil
+	}, webhooks.SubscribeOptions{Buffer: 1})
+	bus.Publish(context.Background(), msg)
+	time.Sleep(10 * time.Millisecond)
+	bus.Publish(context.Background(), msg)
+
+	published := make(chan error, 1)
+	go func() { published <- bus.Publish(context.Background(), msg) }()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	closed := make(chan error, 1)
+	go func() { closed <- bus.Close(ctx) }()
+
+	select {
+	case err := <-published:
+		if !errors.Is(err, webhooks.ErrBusClosed) {
+			t.Errorf("Expected the blocked Publish to fail with ErrBusClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish stayed blocked after Close")
+	}
+	select {
+	case err := <-closed:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected Close to give up at its deadline, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close ignored its context")
+	}
+}
+
+// naiveWallet reads and writes balances in separate steps, so it loses
+// updates unless the router serialises each player's webhooks
+type naiveWallet struct {
+	testWallet
+}
+
+func (w *naiveWallet) Debit(ctx context.Context, playerID string, amount money.Money, transactionID int64) (money.Money, error) {
+	bal, err := w.Balance(ctx, playerID, amount.Currency())
+	if err != nil {
+		return bal, err
+	}
+	if bal.Minor() < amount.Minor() {
+		return bal, webhooks.ErrInsufficientFunds
+	}
+	time.Sleep(time.Millisecond)
+	return w.set(playerID, bal.Minor()-amount.Minor(), amount.Currency())
+}
+
+func (w *naiveWallet) Credit(ctx context.Context, playerID string, amount money.Money, transactionID int64) (money.Money, error) {
+	bal, err := w.Balance(ctx, playerID, amount.Currency())
+	if err != nil {
+		return bal, err
+	}
+	time.Sleep(time.Millisecond)
+	return w.set(playerID, bal.Minor()+amount.Minor(), amount.Currency())
+}
+
+func (w *naiveWallet) set(playerID string, minor int64, currency string) (money.Money, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.balances[playerID] = minor
+	return money.New(minor, currency)
+}
+
+func TestWebhookRouterConcurrentPlayer(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	wallet := &naiveWallet{testWallet{balances: map[string]int64{"p1": 10000, "p2": 10000}}}
+	router := webhooks.NewRouter(handler, wallet, webhooks.RouterOptions{})
+
+	handle := func(body string) map[string]interface{} {
+		p, err := handler.Parse(body)
+		if err != nil {
+			t.Errorf("Failed to parse payload: %v", err)
+			return nil
+		}
+		return router.Handle(context.Background(), p)
+	}
+
+	const rounds = 40
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	record := func(resp map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		bal, ok := resp["balance"].(int64)
+		if resp["status"] != "success" || !ok {
+			t.Errorf("Unexpected response: %v", resp)
+			return
+		}
+		if bal%25 != 0 || bal < 10000-rounds*100 || bal > 10000+rounds*50 {
+			t.Errorf("Inconsistent balance %d", bal)
+		}
+	}
+	for i := 1; i <= rounds; i++ {
+		for n, player := range []string{"p1", "p2"} {
+			wg.Add(3)
+			id := n*1000 + i*2
+			bet := fmt.Sprintf(`{"type":"bet","player_id":"%s","currency":"USD","transaction_id":%d,"amount":100,"round_id":"r%d"}`, player, id, i)
+			win := fmt.Sprintf(`{"type":"win","player_id":"%s","currency":"USD","transaction_id":%d,"amount":75,"round_id":"r%d"}`, player, id+1, i)
+			go func() { defer wg.Done(); record(handle(bet)) }()
+			go func() { defer wg.Done(); record(handle(win)) }()
+			go func() {
+				defer wg.Done()
+				record(handle(fmt.Sprintf(`{"type":"balance_check","player_id":"%s","currency":"USD"}`, player)))
+			}()
+		}
+	}
+	wg.Wait()
+
+	for _, player := range []string{"p1", "p2"} {
+		bal, _ := wallet.Balance(context.Background(), player, "USD")
+		if want := int64(10000 - rounds*25); bal.Minor() != want {
+			t.Errorf("%s: expected final balance %d, got %d", player, want, bal.Minor())
+		}
+	}
+}
+
+func TestWebhookRouterHoldsEarlyWin(t *testing.T) {
+	handler := webhooks.NewHandler(webhookSecret)
+	tracker := webhooks.NewRoundTracker(webhooks.RoundTrackerOptions{})
+	router := webhooks.NewRouter(handler, newTestWallet(map[string]int64{"p1": 1000}), webhooks.RouterOptions{
+		Rounds:  tracker,
+		WinWait: time.Second,
+	})
+	handle := func(body string) map[string]interface{} {
+		p, _ := handler.Parse(body)
+		return router.Handle(context.Background(), p)
+	}
+
+	winResp := make(chan map[string]interface{})
+	go func() {
+		winResp <- handle(`{"type":"win","player_id":"p1","currency":"USD","transaction_id":2,"amount":300,"round_id":"r1"}`)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// A balance check is not blocked by the waiting win
+	if resp := handle(`{"type":"balance_check","player_id":"p1","currency":"USD"}`); resp["balance"] != int64(1000) {
+		t.Errorf("Expected balance 1000 before the bet, got %v", resp)
+	}
+	if resp := handle(`{"type":"bet","player_id":"p1","currency":"USD","transaction_id":1,"amount":100,"round_id":"r1"}`); resp["balance"] != int64(900) {
+		t.Errorf("Expected bet to be applied first, got %v", resp)
+	}
+	select {
+	case resp := <-winResp:
+		if resp["balance"] != int64(1200) {
+			t.Errorf("Expected win after bet, got %v", resp)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Win was not released when its bet arrived")
+	}
+	if round, _ := tracker.Round("r1"); round.HasFlag(webhooks.FlagWinWithoutBet) {
+		t.Error("Held win should not be flagged as a win without bet")
+	}
+
+	// A win whose bet never arrives is processed after the wait
+	start := time.Now()
+	router = webhooks.NewRouter(handler, newTestWallet(map[string]int64{"p1": 1000}), webhooks.RouterOptions{WinWait: 50 * time.Millisecond})
+	if resp := handle(`{"type":"win","player_id":"p1","currency":"USD","transaction_id":3,"amount":300,"round_id":"r9"}`); resp["balance"] != int64(1300) {
+		t.Errorf("Expected orphan win to be credited, got %v", resp)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Orphan win returned after %s, before the wait", elapsed)
+	}
+}
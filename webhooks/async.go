@@ -0,0 +1,271 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrDeadLetterNotFound is returned when replaying an unknown dead letter
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// AsyncOptions enables asynchronous processing for selected webhook types.
+// Bets, wins and rollbacks are always processed synchronously.
+type AsyncOptions struct {
+	// Queue defaults to a MemoryQueue
+	Queue Queue
+	// DeadLetters defaults to MemoryDeadLetters
+	DeadLetters DeadLetterStore
+	// Types to process asynchronously; defaults to rewards. Types the
+	// Router does not know, such as informational notifications, are
+	// passed to Process.
+	Types []string
+	// Process handles queued types that are not wallet transactions
+	Process func(ctx context.Context, p *Payload) error
+	// Workers is the number of concurrent workers; defaults to 4
+	Workers int
+	// MaxAttempts before a job is dead-lettered; defaults to 5
+	MaxAttempts int
+	// Backoff returns the delay before retry n (starting at 1); defaults
+	// to exponential backoff from 1s capped at 1m
+	Backoff func(attempt int) time.Duration
+	// OnDeadLetter is called when a job is dead-lettered
+	OnDeadLetter func(DeadLetter)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error from AsyncOptions.Process as not worth retrying;
+// the job is dead-lettered immediately
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// AsyncProcessor runs the worker pool behind a Router's asynchronous types
+type AsyncProcessor struct {
+	router *Router
+	queue  Queue
+	dead   DeadLetterStore
+	types  map[string]bool
+	opts   AsyncOptions
+}
+
+func newAsyncProcessor(r *Router, opts AsyncOptions) *AsyncProcessor {
+	if opts.Queue == nil {
+		opts.Queue = NewMemoryQueue()
+	}
+	// Jobs are timed with the Router's clock, so the queue must use it too
+	if q, ok := opts.Queue.(clocked); ok {
+		q.SetClock(r.now)
+	}
+	if opts.DeadLetters == nil {
+		opts.DeadLetters = NewMemoryDeadLetters()
+	}
+	if len(opts.Types) == 0 {
+		opts.Types = []string{TypeReward}
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = func(attempt int) time.Duration {
+			d := time.Second << (attempt - 1)
+			if d <= 0 || d > time.Minute {
+				return time.Minute
+			}
+			return d
+		}
+	}
+
+	types := make(map[string]bool)
+	for _, t := range opts.Types {
+		switch t {
+		case TypeBet, TypeWin, TypeRollback, TypeAuthenticate, TypeBalanceCheck:
+			// Balance-critical: always synchronous
+		default:
+			types[t] = true
+		}
+	}
+	return &AsyncProcessor{router: r, queue: opts.Queue, dead: opts.DeadLetters, types: types, opts: opts}
+}
+
+// Async returns the async processor, or nil if RouterOptions.Async was not set
+func (r *Router) Async() *AsyncProcessor {
+	return r.async
+}
+
+// Run starts the workers and blocks until the context is cancelled and all
+// in-flight jobs have finished
+func (a *AsyncProcessor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < a.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// DeadLetters lists up to limit dead letters, oldest first
+func (a *AsyncProcessor) DeadLetters(ctx context.Context, limit int) ([]DeadLetter, error) {
+	return a.dead.List(ctx, limit)
+}
+
+// Replay moves a dead letter back onto the queue with its attempts reset
+func (a *AsyncProcessor) Replay(ctx context.Context, id string) error {
+	dl, ok, err := a.dead.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+
+	job := dl.Job
+	job.Attempts = 0
+	job.LastError = ""
+	job.AvailableAt = a.router.now()
+	if err := a.queue.Enqueue(ctx, job); err != nil {
+		return err
+	}
+	return a.dead.Delete(ctx, id)
+}
+
+// ReplayAll replays every dead letter and returns how many were requeued
+func (a *AsyncProcessor) ReplayAll(ctx context.Context) (int, error) {
+	letters, err := a.dead.List(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+	for i, dl := range letters {
+		if err := a.Replay(ctx, dl.Job.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(letters), nil
+}
+
+func (a *AsyncProcessor) handles(webhookType string) bool {
+	return a.types[webhookType]
+}
+
+// enqueue validates what it can up front, queues the payload and
+// acknowledges it to the provider
+func (a *AsyncProcessor) enqueue(ctx context.Context, p *Payload) map[string]interface{} {
+	r := a.router
+
+	// Known event types are validated now so malformed ones are rejected
+	// while the provider is still waiting
+	if _, err := p.Event(); err != nil && !errors.Is(err, ErrUnknownType) {
+		return r.handler.ErrorResponse(CodeInvalidRequest, err.Error())
+	}
+
+	body, err := json.Marshal(p.Raw)
+	if err != nil {
+		return r.internalError(err)
+	}
+	now := r.now()
+	job := Job{
+		ID:          newJobID(),
+		Type:        p.Type,
+		Payload:     body,
+		EnqueuedAt:  now,
+		AvailableAt: now,
+	}
+	if err := a.queue.Enqueue(ctx, job); err != nil {
+		return r.internalError(err)
+	}
+
+	resp := map[string]interface{}{"status": "success", "queued": true}
+	if p.PlayerID != "" && p.Currency != "" {
+		if bal, err := r.wallet.Balance(ctx, p.PlayerID, p.Currency); err == nil {
+			resp["balance"] = bal.Minor()
+			resp["currency"] = bal.Currency()
+		}
+	}
+	return resp
+}
+
+func (a *AsyncProcessor) work(ctx context.Context) {
+	for {
+		job, err := a.queue.Dequeue(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// Back off briefly on queue errors rather than spinning
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		// Finish the job even if shutdown starts mid-way
+		jobCtx := context.WithoutCancel(ctx)
+		err = a.process(jobCtx, job)
+		if err == nil {
+			a.queue.Ack(jobCtx, job.ID)
+			continue
+		}
+
+		job.Attempts++
+		job.LastError = err.Error()
+		var perm *permanentError
+		if errors.As(err, &perm) || job.Attempts >= a.opts.MaxAttempts {
+			dl := DeadLetter{Job: job, FailedAt: a.router.now()}
+			if putErr := a.dead.Put(jobCtx, dl); putErr != nil {
+				a.queue.Nack(jobCtx, job, a.router.now().Add(a.opts.Backoff(job.Attempts)))
+				continue
+			}
+			a.queue.Ack(jobCtx, job.ID)
+			if a.opts.OnDeadLetter != nil {
+				a.opts.OnDeadLetter(dl)
+			}
+			continue
+		}
+		a.queue.Nack(jobCtx, job, a.router.now().Add(a.opts.Backoff(job.Attempts)))
+	}
+}
+
+func (a *AsyncProcessor) process(ctx context.Context, job Job) error {
+	r := a.router
+	p, err := r.handler.Parse(string(job.Payload))
+	if err != nil {
+		return Permanent(err)
+	}
+
+	if _, err := p.Event(); errors.Is(err, ErrUnknownType) {
+		if a.opts.Process == nil {
+			return Permanent(fmt.Errorf("no processor for webhook type %q", p.Type))
+		}
+		return a.opts.Process(ctx, p)
+	}
+
+	resp := r.dispatch(ctx, p)
+	if resp["status"] != "error" {
+		return nil
+	}
+	err = fmt.Errorf("%v: %v", resp["error_code"], resp["error_message"])
+	if resp["error_code"] == CodeInternalError {
+		return err
+	}
+	return Permanent(err)
+}
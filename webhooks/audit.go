@@ -0,0 +1,300 @@
+package webhooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEntry is the record of one verified webhook and the response sent
+type AuditEntry struct {
+	Time          time.Time         `json:"time"`
+	Type          string            `json:"type,omitempty"`
+	PlayerID      string            `json:"player_id,omitempty"`
+	TransactionID *int64            `json:"transaction_id,omitempty"`
+	Signature     string            `json:"signature"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	// Body is the request body as received. It is base64 encoded in JSON,
+	// so bodies that are not valid UTF-8 are kept and hashed byte for byte.
+	Body       []byte          `json:"body"`
+	StatusCode int             `json:"status_code"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Duration   time.Duration   `json:"duration_ns"`
+}
+
+// AuditRecord is an AuditEntry as stored in a hash-chained log
+type AuditRecord struct {
+	Seq      int64      `json:"seq"`
+	PrevHash string     `json:"prev_hash"`
+	Hash     string     `json:"hash"`
+	Entry    AuditEntry `json:"entry"`
+}
+
+// AuditSink receives an entry for every verified webhook the Router serves.
+// Implementations must be safe for concurrent use.
+type AuditSink interface {
+	Append(ctx context.Context, entry AuditEntry) error
+}
+
+// redactedHeaders are never written to the audit log
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+}
+
+func auditHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		name = http.CanonicalHeaderKey(name)
+		if redactedHeaders[name] {
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// FileAuditLog is an append-only AuditSink writing JSON Lines to a file.
+// Each record carries the SHA-256 of the previous one, so editing, removing
+// or reordering records breaks the chain and is caught by VerifyAuditLog.
+type FileAuditLog struct {
+	mu       sync.Mutex
+	f        *os.File
+	seq      int64
+	lastHash string
+	sync     bool
+}
+
+// FileAuditOptions configures a FileAuditLog
+type FileAuditOptions struct {
+	// Sync flushes the file to disk after every record
+	Sync bool
+}
+
+// OpenFileAuditLog opens or creates the log at path and continues its chain.
+// An existing log whose chain does not verify is refused.
+func OpenFileAuditLog(path string, opts FileAuditOptions) (*FileAuditLog, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &FileAuditLog{f: f, sync: opts.Sync}
+	last, err := verifyAuditLog(f, nil)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("audit log %s: %w", path, err)
+	}
+	if last != nil {
+		l.seq = last.Seq
+		l.lastHash = last.Hash
+	}
+	return l, nil
+}
+
+// Append implements AuditSink
+func (l *FileAuditLog) Append(ctx context.Context, entry AuditEntry) error {
+	entry.Time = entry.Time.UTC()
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return os.ErrClosed
+	}
+
+	seq := l.seq + 1
+	hash := auditHash(seq, l.lastHash, raw)
+	line, err := json.Marshal(struct {
+		Seq      int64           `json:"seq"`
+		PrevHash string          `json:"prev_hash"`
+		Hash     string          `json:"hash"`
+		Entry    json.RawMessage `json:"entry"`
+	}{seq, l.lastHash, hash, raw})
+	if err != nil {
+		return err
+	}
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if l.sync {
+		if err := l.f.Sync(); err != nil {
+			return err
+		}
+	}
+	l.seq = seq
+	l.lastHash = hash
+	return nil
+}
+
+// Head returns the sequence number and hash of the last record. Keeping a copy
+// of the head elsewhere detects truncation of the log's tail.
+func (l *FileAuditLog) Head() (int64, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq, l.lastHash
+}
+
+// Close closes the underlying file
+func (l *FileAuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+func auditHash(seq int64, prevHash string, entry []byte) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatInt(seq, 10)))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(prevHash))
+	h.Write([]byte{'\n'})
+	h.Write(entry)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ErrAuditChainBroken is wrapped by AuditChainError
+var ErrAuditChainBroken = errors.New("audit chain broken")
+
+// AuditChainError reports where a hash-chained audit log fails verification
+type AuditChainError struct {
+	Line   int
+	Seq    int64
+	Reason string
+}
+
+func (e *AuditChainError) Error() string {
+	return fmt.Sprintf("audit chain broken at line %d (seq %d): %s", e.Line, e.Seq, e.Reason)
+}
+
+// Unwrap returns ErrAuditChainBroken
+func (e *AuditChainError) Unwrap() error {
+	return ErrAuditChainBroken
+}
+
+// VerifyAuditLog checks the hash chain of a log written by FileAuditLog and
+// returns the number of records verified and the hash of the last one
+func VerifyAuditLog(r io.Reader) (int64, string, error) {
+	last, err := verifyAuditLog(r, nil)
+	if last == nil {
+		return 0, "", err
+	}
+	return last.Seq, last.Hash, err
+}
+
+// AuditFilter selects records for ExportAuditLog; zero fields match anything
+type AuditFilter struct {
+	From     time.Time
+	To       time.Time
+	PlayerID string
+	Type     string
+}
+
+func (f AuditFilter) match(e AuditEntry) bool {
+	if !f.From.IsZero() && e.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !e.Time.Before(f.To) {
+		return false
+	}
+	if f.PlayerID != "" && e.PlayerID != f.PlayerID {
+		return false
+	}
+	if f.Type != "" && e.Type != f.Type {
+		return false
+	}
+	return true
+}
+
+// ExportAuditLog verifies the log read from r and writes the records matching
+// filter to w as JSON Lines. Nothing past a broken link is exported.
+func ExportAuditLog(w io.Writer, r io.Reader, filter AuditFilter) (int, error) {
+	enc := json.NewEncoder(w)
+	n := 0
+	_, err := verifyAuditLog(r, func(rec AuditRecord) error {
+		if !filter.match(rec.Entry) {
+			return nil
+		}
+		n++
+		return enc.Encode(rec)
+	})
+	return n, err
+}
+
+// verifyAuditLog walks the chain, calling fn for each verified record, and
+// returns the last good record
+func verifyAuditLog(r io.Reader, fn func(AuditRecord) error) (*AuditRecord, error) {
+	var (
+		last     *AuditRecord
+		prevHash string
+		line     int
+	)
+	br := bufio.NewReader(r)
+	for {
+		raw, err := br.ReadBytes('\n')
+		if len(raw) == 0 && err == io.EOF {
+			return last, nil
+		}
+		if err != nil && err != io.EOF {
+			return last, err
+		}
+		line++
+
+		raw = bytes.TrimSpace(raw)
+		var stored struct {
+			Seq      int64           `json:"seq"`
+			PrevHash string          `json:"prev_hash"`
+			Hash     string          `json:"hash"`
+			Entry    json.RawMessage `json:"entry"`
+		}
+		if jsonErr := json.Unmarshal(raw, &stored); jsonErr != nil {
+			return last, &AuditChainError{Line: line, Reason: "malformed record: " + jsonErr.Error()}
+		}
+
+		want := int64(line)
+		switch {
+		case stored.Seq != want:
+			return last, &AuditChainError{Line: line, Seq: stored.Seq, Reason: fmt.Sprintf("expected seq %d", want)}
+		case stored.PrevHash != prevHash:
+			return last, &AuditChainError{Line: line, Seq: stored.Seq, Reason: "previous hash does not match"}
+		case auditHash(stored.Seq, stored.PrevHash, stored.Entry) != stored.Hash:
+			return last, &AuditChainError{Line: line, Seq: stored.Seq, Reason: "record hash does not match contents"}
+		}
+
+		rec := AuditRecord{Seq: stored.Seq, PrevHash: stored.PrevHash, Hash: stored.Hash}
+		if jsonErr := json.Unmarshal(stored.Entry, &rec.Entry); jsonErr != nil {
+			return last, &AuditChainError{Line: line, Seq: stored.Seq, Reason: "malformed entry: " + jsonErr.Error()}
+		}
+		if fn != nil {
+			if err := fn(rec); err != nil {
+				return last, err
+			}
+		}
+		last = &rec
+		prevHash = stored.Hash
+
+		if err == io.EOF {
+			return last, nil
+		}
+	}
+}
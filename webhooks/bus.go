@@ -0,0 +1,358 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrBusClosed is returned when publishing to a closed bus or subscription
+var ErrBusClosed = errors.New("event bus closed")
+
+// ErrBufferFull is returned by Publish when a DropNewest subscriber's buffer
+// is full
+var ErrBufferFull = errors.New("subscriber buffer full")
+
+// Message is published on the Bus after a transaction is committed
+type Message struct {
+	// ID is stable across redeliveries, e.g. "bet:12345"; use it to make
+	// subscribers idempotent
+	ID    string
+	Event Event
+	// Balance is the player's balance after the transaction, in minor units
+	Balance     int64
+	CommittedAt time.Time
+	// Raw is the webhook payload as received
+	Raw map[string]interface{}
+}
+
+// MarshalJSON encodes the message as the envelope sent to brokers
+func (m Message) MarshalJSON() ([]byte, error) {
+	common := m.Event.Common()
+	return json.Marshal(map[string]interface{}{
+		"id":           m.ID,
+		"type":         m.Event.EventType(),
+		"player_id":    common.PlayerID,
+		"currency":     common.Currency,
+		"balance":      m.Balance,
+		"committed_at": m.CommittedAt.UTC().Format(time.RFC3339Nano),
+		"webhook":      m.Raw,
+	})
+}
+
+// OverflowPolicy decides what Publish does when a subscriber's buffer is full
+type OverflowPolicy int
+
+const (
+	// Block makes Publish wait for buffer space, pushing back on the
+	// webhook until the subscriber catches up or the context is done
+	Block OverflowPolicy = iota
+	// DropNewest discards the new message and reports ErrBufferFull
+	DropNewest
+)
+
+// SubscribeOptions configures a subscription
+type SubscribeOptions struct {
+	// Types limits delivery to these webhook types; empty means all
+	Types []string
+	// Buffer is the number of messages held for a slow subscriber;
+	// defaults to 256
+	Buffer int
+	// Overflow defaults to Block
+	Overflow OverflowPolicy
+	// MaxAttempts per message; 0 retries until the handler succeeds or the
+	// bus is closed
+	MaxAttempts int
+	// Backoff returns the delay before retry n (starting at 1); defaults to
+	// exponential backoff from 100ms capped at 30s
+	Backoff func(attempt int) time.Duration
+}
+
+// BusOptions configures a Bus
+type BusOptions struct {
+	// OnError is called when a message is given up on: out of attempts,
+	// dropped on overflow or abandoned at shutdown
+	OnError func(subscriber string, msg Message, err error)
+}
+
+// Bus fans committed transactions out to subscribers in-process. Each
+// subscriber has its own buffer and delivery goroutine, so a slow one does
+// not delay the others, and failed deliveries are retried.
+//
+// Buffers live in memory, so delivery is at most once end to end: messages
+// dropped by DropNewest, abandoned when Close gives up, or buffered when the
+// process exits are lost even though the transaction was committed. Record
+// what subscribers must not miss in your wallet's transaction instead.
+type Bus struct {
+	mu      sync.RWMutex
+	subs    map[*Subscription]bool
+	closed  bool
+	onError func(string, Message, error)
+}
+
+// NewBus creates a new event bus
+func NewBus(opts BusOptions) *Bus {
+	return &Bus{subs: make(map[*Subscription]bool), onError: opts.OnError}
+}
+
+// Subscription is a registered subscriber
+type Subscription struct {
+	name    string
+	bus     *Bus
+	handler func(ctx context.Context, msg Message) error
+	opts    SubscribeOptions
+	types   map[string]bool
+	ch      chan Message
+	stop    chan struct{}
+	abort   chan struct{}
+	done    chan struct{}
+	// sending counts Publish calls that may still write to ch
+	sending sync.WaitGroup
+
+	stopOnce  sync.Once
+	abortOnce sync.Once
+}
+
+// Subscribe registers handler under name. Messages are delivered in publish
+// order, one at a time.
+func (b *Bus) Subscribe(name string, handler func(ctx context.Context, msg Message) error, opts SubscribeOptions) (*Subscription, error) {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 256
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = func(attempt int) time.Duration {
+			d := 100 * time.Millisecond << (attempt - 1)
+			if d <= 0 || d > 30*time.Second {
+				return 30 * time.Second
+			}
+			return d
+		}
+	}
+	s := &Subscription{
+		name:    name,
+		bus:     b,
+		handler: handler,
+		opts:    opts,
+		ch:      make(chan Message, opts.Buffer),
+		stop:    make(chan struct{}),
+		abort:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if len(opts.Types) > 0 {
+		s.types = make(map[string]bool)
+		for _, t := range opts.Types {
+			s.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+	b.subs[s] = true
+	go s.run()
+	return s, nil
+}
+
+// Publish hands msg to every interested subscriber. It returns once the
+// message is buffered everywhere; the error joins ErrBufferFull,
+// ErrBusClosed or the context error for subscribers that did not take it.
+func (b *Bus) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBusClosed
+	}
+	subs := make([]*Subscription, 0, len(b.subs))
+	for s := range b.subs {
+		if s.types != nil && !s.types[msg.Event.EventType()] {
+			continue
+		}
+		// Registered under the lock so Unsubscribe waits for this send
+		s.sending.Add(1)
+		subs = append(subs, s)
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, s := range subs {
+		err := s.offer(ctx, msg)
+		s.sending.Done()
+		if err != nil {
+			b.report(s.name, msg, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops accepting messages and waits for subscribers to drain their
+// buffers. When ctx is done first, pending messages are abandoned and Close
+// returns without waiting for handlers still running.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	subs := make([]*Subscription, 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.Unlock()
+
+	var errs []error
+	for _, s := range subs {
+		if err := s.Unsubscribe(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *Bus) report(name string, msg Message, err error) {
+	if b.onError != nil {
+		b.onError(name, msg, err)
+	}
+}
+
+// Name returns the subscriber name
+func (s *Subscription) Name() string {
+	return s.name
+}
+
+// Pending returns the number of buffered messages
+func (s *Subscription) Pending() int {
+	return len(s.ch)
+}
+
+// Unsubscribe removes the subscription and waits for its buffer to drain.
+// Publish calls blocked on it return ErrBusClosed. When ctx is done first,
+// pending messages are abandoned and the running handler's context is
+// cancelled without waiting for it to return.
+func (s *Subscription) Unsubscribe(ctx context.Context) error {
+	s.bus.mu.Lock()
+	delete(s.bus.subs, s)
+	s.bus.mu.Unlock()
+
+	s.stopOnce.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		s.abortOnce.Do(func() { close(s.abort) })
+		return ctx.Err()
+	}
+}
+
+func (s *Subscription) offer(ctx context.Context, msg Message) error {
+	select {
+	case <-s.stop:
+		return ErrBusClosed
+	default:
+	}
+	if s.opts.Overflow == DropNewest {
+		select {
+		case s.ch <- msg:
+			return nil
+		default:
+			return ErrBufferFull
+		}
+	}
+	select {
+	case s.ch <- msg:
+		return nil
+	case <-s.stop:
+		return ErrBusClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Subscription) run() {
+	defer close(s.done)
+	for {
+		select {
+		case msg := <-s.ch:
+			s.deliver(msg)
+		case <-s.stop:
+			// Drain what was buffered before the stop, including sends
+			// that raced with it
+			s.sending.Wait()
+			for {
+				select {
+				case msg := <-s.ch:
+					s.deliver(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Subscription) deliver(msg Message) {
+	select {
+	case <-s.abort:
+		s.bus.report(s.name, msg, ErrBusClosed)
+		return
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.abort:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for attempt := 1; ; attempt++ {
+		err := s.handler(ctx, msg)
+		if err == nil {
+			return
+		}
+		if s.opts.MaxAttempts > 0 && attempt >= s.opts.MaxAttempts {
+			s.bus.report(s.name, msg, err)
+			return
+		}
+		timer := time.NewTimer(s.opts.Backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-s.abort:
+			timer.Stop()
+			s.bus.report(s.name, msg, ErrBusClosed)
+			return
+		}
+	}
+}
+
+// Broker publishes messages to an external system such as Kafka, NATS or a
+// cloud queue
+type Broker interface {
+	Publish(ctx context.Context, topic string, body []byte) error
+}
+
+// Forward subscribes a broker under name, sending each message as its JSON
+// envelope. topic chooses the destination per message; nil uses
+// "gamehub.webhooks.<type>".
+func (b *Bus) Forward(name string, broker Broker, topic func(Message) string, opts SubscribeOptions) (*Subscription, error) {
+	if topic == nil {
+		topic = func(m Message) string { return "gamehub.webhooks." + m.Event.EventType() }
+	}
+	return b.Subscribe(name, func(ctx context.Context, msg Message) error {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return broker.Publish(ctx, topic(msg), body)
+	}, opts)
+}
+
+// messageID identifies a committed transaction across redeliveries
+func messageID(txType string, transactionID int64) string {
+	return txType + ":" + strconv.FormatInt(transactionID, 10)
+}
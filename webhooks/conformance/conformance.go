@@ -0,0 +1,231 @@
+// Package conformance checks that an operator webhook endpoint honours the
+// wallet protocol, using signed webhooks from the simulator
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks/simulator"
+)
+
+// Config describes the endpoint under test
+type Config struct {
+	// Secret is the webhook secret the endpoint verifies with
+	Secret string
+	// URL of the endpoint; ignored when Handler is set
+	URL string
+	// Handler is an in-process endpoint
+	Handler http.Handler
+	// SignatureHeader defaults to X-Signature
+	SignatureHeader string
+	// HTTPClient is used for URL targets
+	HTTPClient *http.Client
+
+	// PlayerID must exist and hold at least BetAmount*3 in Currency
+	PlayerID string
+	Currency string
+	// UnknownPlayerID must not exist; defaults to "conformance_unknown_player"
+	UnknownPlayerID string
+	// BetAmount and WinAmount in minor units; default to 100 and 250
+	BetAmount int64
+	WinAmount int64
+}
+
+// Check is one protocol requirement
+type Check struct {
+	Name        string
+	Description string
+	run         func(ctx context.Context, sim *simulator.Simulator, cfg Config) (simulator.Report, error)
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Check  Check
+	Report simulator.Report
+	Err    error
+}
+
+// Passed reports whether the check ran and every step met expectations
+func (r Result) Passed() bool {
+	return r.Err == nil && r.Report.Passed()
+}
+
+// String summarises the result
+func (r Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("FAIL %s: %v\n", r.Check.Name, r.Err)
+	}
+	if r.Passed() {
+		return fmt.Sprintf("PASS %s\n", r.Check.Name)
+	}
+	return "FAIL " + r.Report.String()
+}
+
+// Checks returns the standard battery in the order it runs
+func Checks() []Check {
+	return []Check{
+		{Name: "balance_minor_units", Description: "authenticate and balance_check return an integer balance", run: checkBalance},
+		{Name: "bet_and_win", Description: "bets debit and wins credit the exact amount", run: checkBetWin},
+		{Name: "insufficient_funds", Description: "a bet above the balance is refused with INSUFFICIENT_FUNDS", run: checkInsufficientFunds},
+		{Name: "idempotent_duplicates", Description: "repeated transactions do not move the balance twice", run: checkDuplicates},
+		{Name: "rollback_unknown_transaction", Description: "rolling back an unknown transaction succeeds without effect", run: checkUnknownRollback},
+		{Name: "unknown_player", Description: "requests for an unknown player return PLAYER_NOT_FOUND", run: checkUnknownPlayer},
+		{Name: "invalid_signature", Description: "wrong, missing and malformed signatures are rejected with 401", run: checkSignatures},
+	}
+}
+
+// Run executes every check against the configured endpoint
+func Run(ctx context.Context, cfg Config) ([]Result, error) {
+	cfg, err := withDefaults(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sim := simulator.New(simulator.Options{
+		Secret:          cfg.Secret,
+		URL:             cfg.URL,
+		Handler:         cfg.Handler,
+		HTTPClient:      cfg.HTTPClient,
+		SignatureHeader: cfg.SignatureHeader,
+	})
+
+	var results []Result
+	for _, c := range Checks() {
+		report, err := c.run(ctx, sim, cfg)
+		results = append(results, Result{Check: c, Report: report, Err: err})
+	}
+	return results, nil
+}
+
+// RunTest runs every check as a subtest
+func RunTest(t *testing.T, cfg Config) {
+	t.Helper()
+	results, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("conformance: %v", err)
+	}
+	for _, r := range results {
+		r := r
+		t.Run(r.Check.Name, func(t *testing.T) {
+			if !r.Passed() {
+				t.Error(strings.TrimSpace(r.String()))
+			}
+		})
+	}
+}
+
+func withDefaults(cfg Config) (Config, error) {
+	if cfg.Handler == nil && cfg.URL == "" {
+		return cfg, errors.New("either URL or Handler is required")
+	}
+	if cfg.PlayerID == "" || cfg.Currency == "" {
+		return cfg, errors.New("PlayerID and Currency are required")
+	}
+	if cfg.UnknownPlayerID == "" {
+		cfg.UnknownPlayerID = "conformance_unknown_player"
+	}
+	if cfg.BetAmount <= 0 {
+		cfg.BetAmount = 100
+	}
+	if cfg.WinAmount <= 0 {
+		cfg.WinAmount = 250
+	}
+	return cfg, nil
+}
+
+func checkBalance(ctx context.Context, sim *simulator.Simulator, cfg Config) (simulator.Report, error) {
+	return sim.Run(ctx, simulator.Scenario{
+		Name: "balance_minor_units",
+		Steps: []simulator.Step{
+			{Name: "authenticate", Webhook: sim.Authenticate(cfg.PlayerID, cfg.Currency), Expect: simulator.Expect{Status: "success", RequireBalance: true}},
+			{Name: "balance check", Webhook: sim.BalanceCheck(cfg.PlayerID, cfg.Currency), Expect: simulator.Expect{Status: "success", BalanceDelta: simulator.Int64(0)}},
+		},
+	})
+}
+
+func checkBetWin(ctx context.Context, sim *simulator.Simulator, cfg Config) (simulator.Report, error) {
+	round := sim.NewRoundID()
+	return sim.Run(ctx, simulator.Scenario{
+		Name: "bet_and_win",
+		Steps: []simulator.Step{
+			{Name: "balance check", Webhook: sim.BalanceCheck(cfg.PlayerID, cfg.Currency), Expect: simulator.Expect{Status: "success"}},
+			{Name: "bet", Webhook: sim.Bet(cfg.PlayerID, cfg.Currency, cfg.BetAmount, round), Expect: simulator.Expect{Status: "success", BalanceDelta: simulator.Int64(-cfg.BetAmount)}},
+			{Name: "win", Webhook: sim.Win(cfg.PlayerID, cfg.Currency, cfg.WinAmount, round), Expect: simulator.Expect{Status: "success", BalanceDelta: simulator.Int64(cfg.WinAmount)}},
+		},
+	})
+}
+
+func checkInsufficientFunds(ctx context.Context, sim *simulator.Simulator, cfg Config) (simulator.Report, error) {
+	res, err := sim.Send(ctx, sim.BalanceCheck(cfg.PlayerID, cfg.Currency))
+	if err != nil {
+		return simulator.Report{}, err
+	}
+	bal, ok := res.Balance()
+	if !ok {
+		return simulator.Report{}, errors.New("balance check returned no integer balance")
+	}
+
+	return sim.Run(ctx, simulator.Scenario{
+		Name: "insufficient_funds",
+		Steps: []simulator.Step{
+			{Name: "balance check", Webhook: sim.BalanceCheck(cfg.PlayerID, cfg.Currency), Expect: simulator.Expect{Status: "success"}},
+			{
+				Name:    "bet above balance",
+				Webhook: sim.Bet(cfg.PlayerID, cfg.Currency, bal+1, ""),
+				Expect:  simulator.Expect{Status: "error", ErrorCode: "INSUFFICIENT_FUNDS", BalanceDelta: simulator.Int64(0)},
+			},
+		},
+	})
+}
+
+func checkDuplicates(ctx context.Context, sim *simulator.Simulator, cfg Config) (simulator.Report, error) {
+	sc := sim.Duplicates(cfg.PlayerID, cfg.Currency, cfg.BetAmount, cfg.WinAmount)
+	sc.Name = "idempotent_duplicates"
+	return sim.Run(ctx, sc)
+}
+
+func checkUnknownRollback(ctx context.Context, sim *simulator.Simulator, cfg Config) (simulator.Report, error) {
+	never := sim.Bet(cfg.PlayerID, cfg.Currency, cfg.BetAmount, "")
+	return sim.Run(ctx, simulator.Scenario{
+		Name: "rollback_unknown_transaction",
+		Steps: []simulator.Step{
+			{Name: "balance check", Webhook: sim.BalanceCheck(cfg.PlayerID, cfg.Currency), Expect: simulator.Expect{Status: "success"}},
+			{Name: "rollback unknown", Webhook: sim.Rollback(never), Expect: simulator.Expect{Status: "success", BalanceDelta: simulator.Int64(0)}},
+		},
+	})
+}
+
+func checkUnknownPlayer(ctx context.Context, sim *simulator.Simulator, cfg Config) (simulator.Report, error) {
+	notFound := simulator.Expect{Status: "error", ErrorCode: "PLAYER_NOT_FOUND"}
+	return sim.Run(ctx, simulator.Scenario{
+		Name: "unknown_player",
+		Steps: []simulator.Step{
+			{Name: "authenticate", Webhook: sim.Authenticate(cfg.UnknownPlayerID, cfg.Currency), Expect: notFound},
+			{Name: "balance check", Webhook: sim.BalanceCheck(cfg.UnknownPlayerID, cfg.Currency), Expect: notFound},
+			{Name: "bet", Webhook: sim.Bet(cfg.UnknownPlayerID, cfg.Currency, cfg.BetAmount, ""), Expect: notFound},
+		},
+	})
+}
+
+func checkSignatures(ctx context.Context, sim *simulator.Simulator, cfg Config) (simulator.Report, error) {
+	bet := sim.Bet(cfg.PlayerID, cfg.Currency, cfg.BetAmount, "")
+	good := sim.Sign(bet.Body())
+	tampered := bet.With("amount", cfg.BetAmount-1)
+	rejected := simulator.Expect{HTTPStatus: http.StatusUnauthorized}
+
+	return sim.Run(ctx, simulator.Scenario{
+		Name: "invalid_signature",
+		Steps: []simulator.Step{
+			{Name: "balance check", Webhook: sim.BalanceCheck(cfg.PlayerID, cfg.Currency), Expect: simulator.Expect{Status: "success"}},
+			{Name: "wrong secret", Webhook: bet, Signature: strings.Repeat("0", len(good)), Expect: rejected},
+			{Name: "malformed signature", Webhook: bet, Signature: "not-a-signature", Expect: rejected},
+			{Name: "tampered body", Webhook: tampered, Signature: good, Expect: rejected},
+			{Name: "missing signature", Webhook: bet, Signature: simulator.NoSignature, Expect: rejected},
+			{Name: "balance unchanged", Webhook: sim.BalanceCheck(cfg.PlayerID, cfg.Currency), Expect: simulator.Expect{Status: "success", BalanceDelta: simulator.Int64(0)}},
+		},
+	})
+}
@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownType is returned when a payload has a type with no matching event
+var ErrUnknownType = errors.New("unknown webhook type")
+
+// FieldError is returned when a webhook field is missing or invalid for its type
+type FieldError struct {
+	Type   string
+	Field  string
+	Reason string
+}
+
+// Error implements the error interface
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s webhook: %s %s", e.Type, e.Field, e.Reason)
+}
+
+// Event is implemented by every typed webhook event
+type Event interface {
+	// EventType returns the webhook type constant for the event
+	EventType() string
+	// Common returns the fields shared by all webhook types
+	Common() *CommonFields
+}
+
+// CommonFields contains the fields present on every webhook type
+type CommonFields struct {
+	PlayerID  string
+	Currency  string
+	Timestamp string
+	GameID    *int
+	GameType  string
+}
+
+// Common returns the shared fields
+func (c *CommonFields) Common() *CommonFields {
+	return c
+}
+
+// Freespin contains freespin details for bets and wins played as freespins
+type Freespin struct {
+	ID            string
+	Total         *int
+	Remaining     *int
+	RoundNumber   *int
+	TotalWinnings *float64
+}
+
+// AuthenticateEvent is sent when a player opens a game
+type AuthenticateEvent struct {
+	CommonFields
+	SessionID string
+}
+
+// BalanceCheckEvent is sent when the provider needs the current balance
+type BalanceCheckEvent struct {
+	CommonFields
+	SessionID string
+}
+
+// BetEvent is sent when a player places a bet
+type BetEvent struct {
+	CommonFields
+	TransactionID int64
+	Amount        int64 // In cents
+	SessionID     string
+	RoundID       string
+	Freespin      *Freespin
+}
+
+// WinEvent is sent when a player wins
+type WinEvent struct {
+	CommonFields
+	TransactionID int64
+	Amount        int64 // In cents
+	SessionID     string
+	RoundID       string
+	Freespin      *Freespin
+}
+
+// RollbackEvent is sent when a previous transaction must be reversed
+type RollbackEvent struct {
+	CommonFields
+	TransactionID int64
+	Amount        *int64 // In cents, when supplied by the provider
+	SessionID     string
+	RoundID       string
+
+	// ReferenceTransactionID is the transaction being reversed, when the
+	// provider names it explicitly
+	ReferenceTransactionID *int64
+}
+
+// RewardEvent is sent when a promotion or tournament pays out
+type RewardEvent struct {
+	CommonFields
+	TransactionID int64
+	Amount        int64 // In cents
+	RewardType    string
+	RewardTitle   string
+}
+
+// EventType returns TypeAuthenticate
+func (e *AuthenticateEvent) EventType() string { return TypeAuthenticate }
+
+// EventType returns TypeBalanceCheck
+func (e *BalanceCheckEvent) EventType() string { return TypeBalanceCheck }
+
+// EventType returns TypeBet
+func (e *BetEvent) EventType() string { return TypeBet }
+
+// EventType returns TypeWin
+func (e *WinEvent) EventType() string { return TypeWin }
+
+// EventType returns TypeRollback
+func (e *RollbackEvent) EventType() string { return TypeRollback }
+
+// EventType returns TypeReward
+func (e *RewardEvent) EventType() string { return TypeReward }
+
+// Event converts the payload into its typed event, enforcing the fields
+// required by its type. Use a type switch on the result to handle each kind.
+// Missing or negative required fields are reported as a *ValidationError.
+func (p *Payload) Event() (Event, error) {
+	switch p.Type {
+	case TypeAuthenticate, TypeBalanceCheck, TypeBet, TypeWin, TypeRollback, TypeReward:
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownType, p.Type)
+	}
+	if v := p.validate(false); v != nil {
+		return nil, v
+	}
+
+	common := CommonFields{
+		PlayerID:  p.PlayerID,
+		Currency:  p.Currency,
+		Timestamp: p.Timestamp,
+		GameID:    p.GameID,
+		GameType:  p.GameType,
+	}
+
+	switch p.Type {
+	case TypeAuthenticate:
+		return &AuthenticateEvent{CommonFields: common, SessionID: p.SessionID}, nil
+	case TypeBalanceCheck:
+		return &BalanceCheckEvent{CommonFields: common, SessionID: p.SessionID}, nil
+	case TypeBet:
+		return &BetEvent{
+			CommonFields:  common,
+			TransactionID: *p.TransactionID,
+			Amount:        *p.Amount,
+			SessionID:     p.SessionID,
+			RoundID:       p.RoundID,
+			Freespin:      p.freespin(),
+		}, nil
+	case TypeWin:
+		return &WinEvent{
+			CommonFields:  common,
+			TransactionID: *p.TransactionID,
+			Amount:        *p.Amount,
+			SessionID:     p.SessionID,
+			RoundID:       p.RoundID,
+			Freespin:      p.freespin(),
+		}, nil
+	case TypeRollback:
+		return &RollbackEvent{
+			CommonFields:  common,
+			TransactionID: *p.TransactionID,
+			Amount:        p.Amount,
+			SessionID:     p.SessionID,
+			RoundID:       p.RoundID,
+
+			ReferenceTransactionID: p.ReferenceTransactionID,
+		}, nil
+	}
+	return &RewardEvent{
+		CommonFields:  common,
+		TransactionID: *p.TransactionID,
+		Amount:        *p.Amount,
+		RewardType:    p.RewardType,
+		RewardTitle:   p.RewardTitle,
+	}, nil
+}
+
+// ParseEvent parses a webhook payload directly into its typed event
+func (h *Handler) ParseEvent(payload string) (Event, error) {
+	p, err := h.Parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	return p.Event()
+}
+
+func (p *Payload) freespin() *Freespin {
+	if !p.IsFreespin && p.FreespinID == "" {
+		return nil
+	}
+	return &Freespin{
+		ID:            p.FreespinID,
+		Total:         p.FreespinTotal,
+		Remaining:     p.FreespinsRemaining,
+		RoundNumber:   p.FreespinRoundNumber,
+		TotalWinnings: p.FreespinTotalWinnings,
+	}
+}
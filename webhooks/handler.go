@@ -2,11 +2,14 @@
 package webhooks
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
 )
 
 // Webhook type constants
@@ -31,21 +34,24 @@ type Payload struct {
 	GameType string `json:"game_type,omitempty"`
 
 	// Transaction fields
-	TransactionID *int   `json:"transaction_id,omitempty"`
-	Amount        *int   `json:"amount,omitempty"` // In cents
+	TransactionID *int64 `json:"transaction_id,omitempty"`
+	Amount        *int64 `json:"amount,omitempty"` // In cents
 	SessionID     string `json:"session_id,omitempty"`
 	RoundID       string `json:"round_id,omitempty"`
 
+	// Rollback fields
+	ReferenceTransactionID *int64 `json:"reference_transaction_id,omitempty"`
+
 	// Reward fields
 	RewardType  string `json:"reward_type,omitempty"`
 	RewardTitle string `json:"reward_title,omitempty"`
 
 	// Freespin fields
-	IsFreespin           bool     `json:"is_freespin"`
-	FreespinID           string   `json:"freespin_id,omitempty"`
-	FreespinTotal        *int     `json:"freespin_total,omitempty"`
-	FreespinsRemaining   *int     `json:"freespins_remaining,omitempty"`
-	FreespinRoundNumber  *int     `json:"freespin_round_number,omitempty"`
+	IsFreespin            bool     `json:"is_freespin"`
+	FreespinID            string   `json:"freespin_id,omitempty"`
+	FreespinTotal         *int     `json:"freespin_total,omitempty"`
+	FreespinsRemaining    *int     `json:"freespins_remaining,omitempty"`
+	FreespinRoundNumber   *int     `json:"freespin_round_number,omitempty"`
 	FreespinTotalWinnings *float64 `json:"freespin_total_winnings,omitempty"`
 
 	// Raw data
@@ -83,6 +89,9 @@ func (p *Payload) IsBalanceCheck() bool {
 }
 
 // GetAmountInDollars gets amount in dollars (converts from cents)
+//
+// Deprecated: the float result mis-rounds and assumes two decimal places.
+// Use AmountMoney instead.
 func (p *Payload) GetAmountInDollars() *float64 {
 	if p.Amount == nil {
 		return nil
@@ -91,60 +100,115 @@ func (p *Payload) GetAmountInDollars() *float64 {
 	return &dollars
 }
 
+// AmountMoney returns the amount as Money in the payload currency
+func (p *Payload) AmountMoney() (money.Money, error) {
+	if p.Amount == nil {
+		return money.Money{}, errors.New("payload has no amount")
+	}
+	return money.New(*p.Amount, p.Currency)
+}
+
 // Get gets a value from the raw data
 func (p *Payload) Get(key string) interface{} {
 	return p.Raw[key]
 }
 
+// GetFloat64 reads a raw numeric value as a float64. Raw numbers are
+// json.Number, so use this instead of asserting Get(key).(float64).
+func (p *Payload) GetFloat64(key string) (float64, bool) {
+	v, err := getFloat(p.Raw, key)
+	if err != nil || v == nil {
+		return 0, false
+	}
+	return *v, true
+}
+
 // Handler handles webhook verification and parsing
 type Handler struct {
-	secret string
+	secret   string
+	mode     ValidationMode
+	headers  []string
+	encoding SignatureEncoding
+	maxBody  int64
 }
 
 // NewHandler creates a new webhook handler
 func NewHandler(secret string) *Handler {
-	return &Handler{secret: secret}
+	return &Handler{
+		secret:  secret,
+		headers: []string{DefaultSignatureHeader},
+		maxBody: DefaultMaxBodyBytes,
+	}
+}
+
+// Sign computes the signature the provider sends for a payload
+func (h *Handler) Sign(payload string) string {
+	return h.SignBytes([]byte(payload))
 }
 
-// Verify verifies webhook signature
+// Verify verifies webhook signature. Pass the body exactly as received;
+// re-serialised JSON will not match. Prefer VerifyBytes or VerifyRequest.
 func (h *Handler) Verify(payload, signature string) bool {
-	mac := hmac.New(sha256.New, []byte(h.secret))
-	mac.Write([]byte(payload))
-	expected := hex.EncodeToString(mac.Sum(nil))
-	return hmac.Equal([]byte(expected), []byte(signature))
+	return h.VerifyBytes([]byte(payload), signature)
 }
 
-// Parse parses webhook payload
+// Parse parses webhook payload. Numbers are decoded losslessly, so 64-bit
+// transaction IDs survive intact, and numeric fields may also be sent as
+// strings. A field with an unusable value is reported as a *ParseError. In
+// Strict mode a payload failing its schema is reported as a *ValidationError.
 func (h *Handler) Parse(payload string) (*Payload, error) {
+	return h.parse(strings.NewReader(payload))
+}
+
+// ParseBytes parses a raw webhook body, as Parse does
+func (h *Handler) ParseBytes(body []byte) (*Payload, error) {
+	return h.parse(bytes.NewReader(body))
+}
+
+func (h *Handler) parse(r io.Reader) (*Payload, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
 	var raw map[string]interface{}
-	if err := json.Unmarshal([]byte(payload), &raw); err != nil {
+	if err := dec.Decode(&raw); err != nil {
+		return nil, errors.New("invalid JSON payload")
+	}
+	// Anything after the object, even another object, is not one payload
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
 		return nil, errors.New("invalid JSON payload")
 	}
 
 	p := &Payload{
-		Type:      getString(raw, "type"),
-		PlayerID:  getString(raw, "player_id"),
-		Currency:  getString(raw, "currency"),
-		Timestamp: getString(raw, "timestamp"),
-		GameType:  getString(raw, "game_type"),
-		SessionID: getString(raw, "session_id"),
-		RoundID:   getString(raw, "round_id"),
+		Type:        getString(raw, "type"),
+		PlayerID:    getString(raw, "player_id"),
+		Currency:    getString(raw, "currency"),
+		Timestamp:   getString(raw, "timestamp"),
+		GameType:    getString(raw, "game_type"),
+		SessionID:   getString(raw, "session_id"),
+		RoundID:     getString(raw, "round_id"),
 		RewardType:  getString(raw, "reward_type"),
 		RewardTitle: getString(raw, "reward_title"),
-		Raw:       raw,
+		Raw:         raw,
 	}
 
-	if v, ok := raw["game_id"].(float64); ok {
-		i := int(v)
-		p.GameID = &i
+	var err error
+	if p.GameID, err = getInt(raw, "game_id"); err != nil {
+		return nil, err
+	}
+	if p.TransactionID, err = getInt64(raw, "transaction_id"); err != nil {
+		return nil, err
 	}
-	if v, ok := raw["transaction_id"].(float64); ok {
-		i := int(v)
-		p.TransactionID = &i
+	if p.Amount, err = getInt64(raw, "amount"); err != nil {
+		return nil, err
 	}
-	if v, ok := raw["amount"].(float64); ok {
-		i := int(v)
-		p.Amount = &i
+
+	for _, key := range []string{"reference_transaction_id", "original_transaction_id", "ref_transaction_id"} {
+		if raw[key] != nil {
+			if p.ReferenceTransactionID, err = getInt64(raw, key); err != nil {
+				return nil, err
+			}
+			break
+		}
 	}
 
 	// Freespin fields
@@ -160,32 +224,36 @@ func (h *Handler) Parse(payload string) (*Payload, error) {
 		p.FreespinID = v
 	}
 
-	if v, ok := raw["freespin_total"].(float64); ok {
-		i := int(v)
-		p.FreespinTotal = &i
+	if p.FreespinTotal, err = getInt(raw, "freespin_total"); err != nil {
+		return nil, err
 	}
-	if v, ok := raw["freespins_remaining"].(float64); ok {
-		i := int(v)
-		p.FreespinsRemaining = &i
-	} else if v, ok := raw["freespin_left"].(float64); ok {
-		i := int(v)
-		p.FreespinsRemaining = &i
+	if raw["freespins_remaining"] != nil {
+		p.FreespinsRemaining, err = getInt(raw, "freespins_remaining")
+	} else {
+		p.FreespinsRemaining, err = getInt(raw, "freespin_left")
 	}
-	if v, ok := raw["freespin_round_number"].(float64); ok {
-		i := int(v)
-		p.FreespinRoundNumber = &i
+	if err != nil {
+		return nil, err
 	}
-	if v, ok := raw["freespin_total_winnings"].(float64); ok {
-		p.FreespinTotalWinnings = &v
+	if p.FreespinRoundNumber, err = getInt(raw, "freespin_round_number"); err != nil {
+		return nil, err
+	}
+	if p.FreespinTotalWinnings, err = getFloat(raw, "freespin_total_winnings"); err != nil {
+		return nil, err
 	}
 
+	if h.mode == Strict {
+		if v := p.validate(true); v != nil {
+			return nil, v
+		}
+	}
 	return p, nil
 }
 
 // VerifyAndParse verifies and parses webhook in one step
 func (h *Handler) VerifyAndParse(payload, signature string) (*Payload, error) {
 	if !h.Verify(payload, signature) {
-		return nil, errors.New("invalid webhook signature")
+		return nil, ErrInvalidSignature
 	}
 	return h.Parse(payload)
 }
@@ -194,7 +262,7 @@ func (h *Handler) VerifyAndParse(payload, signature string) (*Payload, error) {
 func (h *Handler) SuccessResponse(balance float64, extra map[string]interface{}) map[string]interface{} {
 	resp := map[string]interface{}{
 		"status":  "success",
-		"balance": int(balance * 100),
+		"balance": toCents(balance),
 	}
 	for k, v := range extra {
 		resp[k] = v
@@ -219,7 +287,7 @@ func (h *Handler) PlayerNotFoundResponse() map[string]interface{} {
 // InsufficientFundsResponse creates an insufficient funds error response
 func (h *Handler) InsufficientFundsResponse(balance float64) map[string]interface{} {
 	resp := h.ErrorResponse("INSUFFICIENT_FUNDS", "Insufficient funds")
-	resp["balance"] = int(balance * 100)
+	resp["balance"] = toCents(balance)
 	return resp
 }
 
@@ -228,9 +296,36 @@ func (h *Handler) AlreadyProcessedResponse(balance float64) map[string]interface
 	return h.SuccessResponse(balance, map[string]interface{}{"already_processed": true})
 }
 
-func getString(m map[string]interface{}, key string) string {
-	if v, ok := m[key].(string); ok {
-		return v
+// SuccessResponseMoney creates a success response with the balance in minor units
+func (h *Handler) SuccessResponseMoney(balance money.Money, extra map[string]interface{}) map[string]interface{} {
+	resp := map[string]interface{}{
+		"status":   "success",
+		"balance":  balance.Minor(),
+		"currency": balance.Currency(),
+	}
+	for k, v := range extra {
+		resp[k] = v
 	}
-	return ""
+	return resp
+}
+
+// InsufficientFundsResponseMoney creates an insufficient funds error response
+// with the balance in minor units
+func (h *Handler) InsufficientFundsResponseMoney(balance money.Money) map[string]interface{} {
+	resp := h.ErrorResponse("INSUFFICIENT_FUNDS", "Insufficient funds")
+	resp["balance"] = balance.Minor()
+	resp["currency"] = balance.Currency()
+	return resp
+}
+
+// AlreadyProcessedResponseMoney creates a transaction already processed response
+// with the balance in minor units
+func (h *Handler) AlreadyProcessedResponseMoney(balance money.Money) map[string]interface{} {
+	return h.SuccessResponseMoney(balance, map[string]interface{}{"already_processed": true})
+}
+
+// toCents converts a dollar balance to cents, rounding rather than truncating
+// so that values like 0.29 do not become 28
+func toCents(balance float64) int {
+	return int(math.Round(balance * 100))
 }
@@ -0,0 +1,267 @@
+package webhooks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+)
+
+// LedgerEntry is a processed transaction as recorded by the Router
+type LedgerEntry struct {
+	TransactionID int64
+	Type          string
+	PlayerID      string
+	RoundID       string
+	Amount        money.Money
+	At            time.Time
+
+	// RolledBackBy is the ID of the rollback that reversed this entry, if any
+	RolledBackBy *int64
+	// ReversedID is the ID of the entry a rollback reversed, if any
+	ReversedID *int64
+}
+
+// Tombstone records that a rollback arrived before the transaction it
+// reverses. It names the transaction when the rollback does; otherwise it
+// covers every bet and win of the player's round that arrives later.
+type Tombstone struct {
+	PlayerID      string
+	TransactionID *int64
+	RoundID       string
+	RollbackID    int64
+	At            time.Time
+}
+
+// Ledger stores processed transactions so that duplicates are recognised and
+// rollbacks can find the transaction they reverse. Implementations must be
+// safe for concurrent use.
+type Ledger interface {
+	// Get returns the entry recorded for a transaction of the given type
+	Get(ctx context.Context, txType string, transactionID int64) (LedgerEntry, bool, error)
+	// Put records a processed transaction
+	Put(ctx context.Context, entry LedgerEntry) error
+	// Round returns a player's entries for a round, oldest first
+	Round(ctx context.Context, playerID, roundID string) ([]LedgerEntry, error)
+	// MarkRolledBack links an entry to the rollback that reversed it. It
+	// returns false if the entry was already rolled back.
+	MarkRolledBack(ctx context.Context, txType string, transactionID, rollbackID int64) (bool, error)
+	// Tombstone records that a transaction or round was rolled back before
+	// it arrived
+	Tombstone(ctx context.Context, t Tombstone) error
+	// IsTombstoned reports whether a player's transaction, or its round when
+	// roundID is set, was rolled back before it arrived
+	IsTombstoned(ctx context.Context, playerID, roundID string, transactionID int64) (bool, error)
+}
+
+// DefaultLedgerRetention is how long a MemoryLedger keeps entries and
+// tombstones
+const DefaultLedgerRetention = 24 * time.Hour
+
+type ledgerKey struct {
+	txType string
+	id     int64
+}
+
+type ledgerRound struct {
+	playerID string
+	roundID  string
+}
+
+type ledgerTx struct {
+	playerID string
+	id       int64
+}
+
+// MemoryLedger is an in-process Ledger, suitable for tests and single
+// instance deployments. Entries and tombstones older than the retention are
+// dropped as new ones are written.
+type MemoryLedger struct {
+	mu         sync.Mutex
+	entries    map[ledgerKey]*LedgerEntry
+	rounds     map[ledgerRound][]*LedgerEntry
+	txTombs    map[ledgerTx]Tombstone
+	roundTombs map[ledgerRound]Tombstone
+	retention  time.Duration
+	pruned     time.Time
+}
+
+// NewMemoryLedger creates an empty in-memory ledger keeping entries for
+// DefaultLedgerRetention
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{
+		entries:    make(map[ledgerKey]*LedgerEntry),
+		rounds:     make(map[ledgerRound][]*LedgerEntry),
+		txTombs:    make(map[ledgerTx]Tombstone),
+		roundTombs: make(map[ledgerRound]Tombstone),
+		retention:  DefaultLedgerRetention,
+	}
+}
+
+// SetRetention sets how long entries and tombstones are kept, measured by
+// their At time; 0 or less keeps them until Prune removes them. A
+// rollback arriving after its transaction was dropped tombstones it instead.
+func (l *MemoryLedger) SetRetention(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.retention = d
+}
+
+// Get implements Ledger
+func (l *MemoryLedger) Get(ctx context.Context, txType string, transactionID int64) (LedgerEntry, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[ledgerKey{txType, transactionID}]
+	if !ok {
+		return LedgerEntry{}, false, nil
+	}
+	return *e, true, nil
+}
+
+// Put implements Ledger
+func (l *MemoryLedger) Put(ctx context.Context, entry LedgerEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := ledgerKey{entry.Type, entry.TransactionID}
+	if e, ok := l.entries[key]; ok && e.PlayerID == entry.PlayerID && e.RoundID == entry.RoundID {
+		*e = entry
+		return nil
+	} else if ok {
+		l.unindex(e)
+	}
+	e := &entry
+	l.entries[key] = e
+	if entry.RoundID != "" {
+		round := ledgerRound{entry.PlayerID, entry.RoundID}
+		l.rounds[round] = append(l.rounds[round], e)
+	}
+	l.expire(entry.At)
+	return nil
+}
+
+// Round implements Ledger
+func (l *MemoryLedger) Round(ctx context.Context, playerID, roundID string) ([]LedgerEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := l.rounds[ledgerRound{playerID, roundID}]
+	result := make([]LedgerEntry, len(entries))
+	for i, e := range entries {
+		result[i] = *e
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].At.Equal(result[j].At) {
+			return result[i].At.Before(result[j].At)
+		}
+		return result[i].TransactionID < result[j].TransactionID
+	})
+	return result, nil
+}
+
+// MarkRolledBack implements Ledger
+func (l *MemoryLedger) MarkRolledBack(ctx context.Context, txType string, transactionID, rollbackID int64) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[ledgerKey{txType, transactionID}]
+	if !ok || e.RolledBackBy != nil {
+		return false, nil
+	}
+	e.RolledBackBy = &rollbackID
+	return true, nil
+}
+
+// Tombstone implements Ledger
+func (l *MemoryLedger) Tombstone(ctx context.Context, t Tombstone) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t.TransactionID != nil {
+		l.txTombs[ledgerTx{t.PlayerID, *t.TransactionID}] = t
+	} else {
+		l.roundTombs[ledgerRound{t.PlayerID, t.RoundID}] = t
+	}
+	l.expire(t.At)
+	return nil
+}
+
+// IsTombstoned implements Ledger
+func (l *MemoryLedger) IsTombstoned(ctx context.Context, playerID, roundID string, transactionID int64) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.txTombs[ledgerTx{playerID, transactionID}]; ok {
+		return true, nil
+	}
+	if roundID == "" {
+		return false, nil
+	}
+	_, ok := l.roundTombs[ledgerRound{playerID, roundID}]
+	return ok, nil
+}
+
+// Prune removes entries and tombstones recorded before the given time and
+// returns how many were removed
+func (l *MemoryLedger) Prune(before time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.prune(before)
+}
+
+// expire prunes past the retention, at most a few times per retention
+// period so writes stay cheap
+func (l *MemoryLedger) expire(now time.Time) {
+	if l.retention <= 0 || now.Sub(l.pruned) < l.retention/8 {
+		return
+	}
+	l.pruned = now
+	l.prune(now.Add(-l.retention))
+}
+
+func (l *MemoryLedger) prune(before time.Time) int {
+	removed := 0
+	for key, e := range l.entries {
+		if e.At.Before(before) {
+			delete(l.entries, key)
+			l.unindex(e)
+			removed++
+		}
+	}
+	for key, t := range l.txTombs {
+		if t.At.Before(before) {
+			delete(l.txTombs, key)
+			removed++
+		}
+	}
+	for key, t := range l.roundTombs {
+		if t.At.Before(before) {
+			delete(l.roundTombs, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// unindex removes an entry from its round
+func (l *MemoryLedger) unindex(e *LedgerEntry) {
+	if e.RoundID == "" {
+		return
+	}
+	round := ledgerRound{e.PlayerID, e.RoundID}
+	entries := l.rounds[round]
+	for i, c := range entries {
+		if c == e {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(l.rounds, round)
+	} else {
+		l.rounds[round] = entries
+	}
+}
@@ -0,0 +1,131 @@
+package webhooks
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// keyedMutex hands out one mutex per key, dropping it when no one holds or
+// waits for it
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// Lock locks key and returns the function that unlocks it
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// betWaiters lets early wins wait for the bet of their round
+type betWaiters struct {
+	mu      sync.Mutex
+	waiters map[string]*betWaiter
+}
+
+type betWaiter struct {
+	ch   chan struct{}
+	refs int
+}
+
+func newBetWaiters() *betWaiters {
+	return &betWaiters{waiters: make(map[string]*betWaiter)}
+}
+
+func roundKey(playerID, roundID string) string {
+	return playerID + "\x00" + roundID
+}
+
+// wait registers interest in key and returns a channel closed when a bet
+// for it commits, and the function to call when done waiting
+func (b *betWaiters) wait(key string) (<-chan struct{}, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	w, ok := b.waiters[key]
+	if !ok {
+		w = &betWaiter{ch: make(chan struct{})}
+		b.waiters[key] = w
+	}
+	w.refs++
+	return w.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		w.refs--
+		if w.refs == 0 && b.waiters[key] == w {
+			delete(b.waiters, key)
+		}
+	}
+}
+
+// notify wakes the wins waiting on key
+func (b *betWaiters) notify(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if w, ok := b.waiters[key]; ok {
+		close(w.ch)
+		delete(b.waiters, key)
+	}
+}
+
+// awaitBet holds back a win whose round has no bet yet, until the bet
+// commits or the wait times out; the win is processed either way. Freespin
+// wins and wins without a round are not held.
+func (r *Router) awaitBet(ctx context.Context, e *WinEvent) {
+	if r.winWait <= 0 || e.RoundID == "" || e.Freespin != nil {
+		return
+	}
+
+	// Register before looking, so a bet committing in between is not missed
+	key := roundKey(e.PlayerID, e.RoundID)
+	ready, done := r.waiters.wait(key)
+	defer done()
+
+	if _, ok, err := r.ledger.Get(ctx, TypeWin, e.TransactionID); err != nil || ok {
+		return
+	}
+	entries, err := r.ledger.Round(ctx, e.PlayerID, e.RoundID)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.Type == TypeBet {
+			return
+		}
+	}
+
+	timer := time.NewTimer(r.winWait)
+	defer timer.Stop()
+	select {
+	case <-ready:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
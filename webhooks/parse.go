@@ -0,0 +1,107 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ParseError is returned when a webhook field is present but its value
+// cannot be decoded into the expected type
+type ParseError struct {
+	Field string
+	Value interface{}
+	Err   error
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("webhook field %s: cannot parse %v: %v", e.Field, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying conversion error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// getString reads a string field, accepting numbers in their exact JSON form
+// so numeric player or round IDs are not dropped
+func getString(m map[string]interface{}, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
+
+// getInt64 reads an integer field given either as a JSON number or a
+// string. Whole numbers written as decimals, such as 100.0 or 1e2, are
+// accepted; a fractional part is an error.
+func getInt64(m map[string]interface{}, key string) (*int64, error) {
+	var s string
+	switch v := m[key].(type) {
+	case nil:
+		return nil, nil
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+		if s == "" {
+			return nil, nil
+		}
+	default:
+		return nil, &ParseError{Field: key, Value: v, Err: fmt.Errorf("unexpected %T", v)}
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok || !r.IsInt() || !r.Num().IsInt64() {
+			return nil, &ParseError{Field: key, Value: m[key], Err: err}
+		}
+		i = r.Num().Int64()
+	}
+	return &i, nil
+}
+
+// getInt reads an integer field that must fit in an int
+func getInt(m map[string]interface{}, key string) (*int, error) {
+	v, err := getInt64(m, key)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if *v > math.MaxInt || *v < math.MinInt {
+		return nil, &ParseError{Field: key, Value: m[key], Err: strconv.ErrRange}
+	}
+	i := int(*v)
+	return &i, nil
+}
+
+// getFloat reads a decimal field given either as a JSON number or a string
+func getFloat(m map[string]interface{}, key string) (*float64, error) {
+	var s string
+	switch v := m[key].(type) {
+	case nil:
+		return nil, nil
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+		if s == "" {
+			return nil, nil
+		}
+	default:
+		return nil, &ParseError{Field: key, Value: v, Err: fmt.Errorf("unexpected %T", v)}
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, &ParseError{Field: key, Value: m[key], Err: err}
+	}
+	return &f, nil
+}
@@ -0,0 +1,228 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job is a queued webhook awaiting asynchronous processing
+type Job struct {
+	ID          string
+	Type        string
+	Payload     []byte
+	Attempts    int
+	LastError   string
+	EnqueuedAt  time.Time
+	AvailableAt time.Time
+}
+
+// DeadLetter is a job that failed permanently or ran out of attempts
+type DeadLetter struct {
+	Job      Job
+	FailedAt time.Time
+}
+
+// Queue holds jobs for the async workers. Implementations must be safe for
+// concurrent use.
+type Queue interface {
+	// Enqueue adds a job
+	Enqueue(ctx context.Context, job Job) error
+	// Dequeue blocks until a job is available or the context is done. The
+	// job stays reserved until it is acked or nacked.
+	Dequeue(ctx context.Context) (Job, error)
+	// Ack removes a reserved job after it was processed
+	Ack(ctx context.Context, id string) error
+	// Nack returns a reserved job to the queue, available again at retryAt
+	Nack(ctx context.Context, job Job, retryAt time.Time) error
+}
+
+// DeadLetterStore keeps jobs that could not be processed
+type DeadLetterStore interface {
+	Put(ctx context.Context, dl DeadLetter) error
+	Get(ctx context.Context, id string) (DeadLetter, bool, error)
+	// List returns up to limit dead letters, oldest first; limit <= 0 means all
+	List(ctx context.Context, limit int) ([]DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// clocked is implemented by queues that compare job times to a clock. The
+// Router hands them its own, which also sets the jobs' times.
+type clocked interface {
+	SetClock(now func() time.Time)
+}
+
+// MemoryQueue is an in-process Queue. Jobs are lost when the process exits;
+// use SQLQueue for durability.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	pending  []Job
+	reserved map[string]Job
+	wake     chan struct{}
+	now      func() time.Time
+}
+
+// NewMemoryQueue creates an empty in-memory queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		reserved: make(map[string]Job),
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// SetClock sets the clock jobs' AvailableAt is compared to; a Router sets
+// its own
+func (q *MemoryQueue) SetClock(now func() time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.now = now
+}
+
+// Enqueue implements Queue
+func (q *MemoryQueue) Enqueue(ctx context.Context, job Job) error {
+	q.mu.Lock()
+	q.pending = append(q.pending, job)
+	q.mu.Unlock()
+	q.signal()
+	return nil
+}
+
+// Dequeue implements Queue
+func (q *MemoryQueue) Dequeue(ctx context.Context) (Job, error) {
+	for {
+		q.mu.Lock()
+		now := q.now()
+		next := time.Time{}
+		for i, job := range q.pending {
+			if !job.AvailableAt.After(now) {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				q.reserved[job.ID] = job
+				more := len(q.pending) > 0
+				q.mu.Unlock()
+				if more {
+					// Let another waiting worker look at the rest
+					q.signal()
+				}
+				return job, nil
+			}
+			if next.IsZero() || job.AvailableAt.Before(next) {
+				next = job.AvailableAt
+			}
+		}
+		q.mu.Unlock()
+
+		var (
+			timer *time.Timer
+			ready <-chan time.Time
+		)
+		if !next.IsZero() {
+			timer = time.NewTimer(next.Sub(now))
+			ready = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-q.wake:
+		case <-ready:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return Job{}, err
+		}
+	}
+}
+
+// Ack implements Queue
+func (q *MemoryQueue) Ack(ctx context.Context, id string) error {
+	q.mu.Lock()
+	delete(q.reserved, id)
+	q.mu.Unlock()
+	return nil
+}
+
+// Nack implements Queue
+func (q *MemoryQueue) Nack(ctx context.Context, job Job, retryAt time.Time) error {
+	job.AvailableAt = retryAt
+	q.mu.Lock()
+	delete(q.reserved, job.ID)
+	q.pending = append(q.pending, job)
+	q.mu.Unlock()
+	q.signal()
+	return nil
+}
+
+// Len returns the number of pending and reserved jobs
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending) + len(q.reserved)
+}
+
+func (q *MemoryQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// MemoryDeadLetters is an in-process DeadLetterStore
+type MemoryDeadLetters struct {
+	mu      sync.Mutex
+	letters map[string]DeadLetter
+}
+
+// NewMemoryDeadLetters creates an empty in-memory dead-letter store
+func NewMemoryDeadLetters() *MemoryDeadLetters {
+	return &MemoryDeadLetters{letters: make(map[string]DeadLetter)}
+}
+
+// Put implements DeadLetterStore
+func (s *MemoryDeadLetters) Put(ctx context.Context, dl DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters[dl.Job.ID] = dl
+	return nil
+}
+
+// Get implements DeadLetterStore
+func (s *MemoryDeadLetters) Get(ctx context.Context, id string) (DeadLetter, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dl, ok := s.letters[id]
+	return dl, ok, nil
+}
+
+// List implements DeadLetterStore
+func (s *MemoryDeadLetters) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	s.mu.Lock()
+	result := make([]DeadLetter, 0, len(s.letters))
+	for _, dl := range s.letters {
+		result = append(result, dl)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].FailedAt.Before(result[j].FailedAt) })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// Delete implements DeadLetterStore
+func (s *MemoryDeadLetters) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.letters, id)
+	return nil
+}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
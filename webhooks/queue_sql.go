@@ -0,0 +1,286 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SQLOptions configures SQLQueue and SQLDeadLetters
+type SQLOptions struct {
+	// JobsTable defaults to webhook_jobs
+	JobsTable string
+	// DeadLettersTable defaults to webhook_dead_letters
+	DeadLettersTable string
+	// DollarPlaceholders uses $1, $2... (PostgreSQL) instead of ?
+	DollarPlaceholders bool
+	// PollInterval is how often an idle Dequeue checks for new jobs;
+	// defaults to 1 second
+	PollInterval time.Duration
+	// Visibility is how long a dequeued job stays reserved before another
+	// worker may take it over; defaults to 5 minutes
+	Visibility time.Duration
+	// Now overrides the clock, mainly for tests; a Router sets its own
+	Now func() time.Time
+}
+
+func (o SQLOptions) withDefaults() SQLOptions {
+	if o.JobsTable == "" {
+		o.JobsTable = "webhook_jobs"
+	}
+	if o.DeadLettersTable == "" {
+		o.DeadLettersTable = "webhook_dead_letters"
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.Visibility <= 0 {
+		o.Visibility = 5 * time.Minute
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+func (o SQLOptions) bind(query string) string {
+	if !o.DollarPlaceholders {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SQLQueue is a durable Queue stored in a database/sql table. Reservations
+// expire after the visibility timeout, so jobs held by a crashed worker are
+// picked up again.
+type SQLQueue struct {
+	db   *sql.DB
+	opts SQLOptions
+	// clock starts as opts.Now; a Router may replace it while workers run
+	mu    sync.Mutex
+	clock func() time.Time
+}
+
+// NewSQLQueue creates a queue backed by db. Call Migrate to create the table.
+func NewSQLQueue(db *sql.DB, opts SQLOptions) *SQLQueue {
+	opts = opts.withDefaults()
+	return &SQLQueue{db: db, opts: opts, clock: opts.Now}
+}
+
+// SetClock sets the clock jobs' availability and reservations are compared
+// to; a Router sets its own
+func (q *SQLQueue) SetClock(now func() time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.clock = now
+}
+
+func (q *SQLQueue) now() time.Time {
+	q.mu.Lock()
+	now := q.clock
+	q.mu.Unlock()
+	return now()
+}
+
+// Migrate creates the jobs table if it does not exist
+func (q *SQLQueue) Migrate(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(64) PRIMARY KEY,
+	type VARCHAR(64) NOT NULL,
+	payload TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	last_error TEXT NOT NULL,
+	enqueued_at TIMESTAMP NOT NULL,
+	available_at TIMESTAMP NOT NULL,
+	reserved_until TIMESTAMP NULL
+)`, q.opts.JobsTable))
+	return err
+}
+
+// Enqueue implements Queue
+func (q *SQLQueue) Enqueue(ctx context.Context, job Job) error {
+	_, err := q.db.ExecContext(ctx, q.opts.bind(fmt.Sprintf(
+		`INSERT INTO %s (id, type, payload, attempts, last_error, enqueued_at, available_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		q.opts.JobsTable)),
+		job.ID, job.Type, string(job.Payload), job.Attempts, job.LastError, job.EnqueuedAt.UTC(), job.AvailableAt.UTC())
+	return err
+}
+
+// Dequeue implements Queue by polling for an available job and reserving it
+// with a conditional update, which works without row locking support
+func (q *SQLQueue) Dequeue(ctx context.Context) (Job, error) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, ok, err := q.claim(ctx)
+		if err != nil || ok {
+			return job, err
+		}
+		select {
+		case <-ctx.Done():
+			return Job{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *SQLQueue) claim(ctx context.Context) (Job, bool, error) {
+	now := q.now().UTC()
+	rows, err := q.db.QueryContext(ctx, q.opts.bind(fmt.Sprintf(
+		`SELECT id, type, payload, attempts, last_error, enqueued_at, available_at FROM %s
+WHERE available_at <= ? AND (reserved_until IS NULL OR reserved_until < ?)
+ORDER BY available_at LIMIT 10`, q.opts.JobsTable)), now, now)
+	if err != nil {
+		return Job{}, false, err
+	}
+	var candidates []Job
+	for rows.Next() {
+		var (
+			job     Job
+			payload string
+		)
+		if err := rows.Scan(&job.ID, &job.Type, &payload, &job.Attempts, &job.LastError, &job.EnqueuedAt, &job.AvailableAt); err != nil {
+			rows.Close()
+			return Job{}, false, err
+		}
+		job.Payload = []byte(payload)
+		candidates = append(candidates, job)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Job{}, false, err
+	}
+
+	for _, job := range candidates {
+		res, err := q.db.ExecContext(ctx, q.opts.bind(fmt.Sprintf(
+			`UPDATE %s SET reserved_until = ? WHERE id = ? AND (reserved_until IS NULL OR reserved_until < ?)`,
+			q.opts.JobsTable)), now.Add(q.opts.Visibility), job.ID, now)
+		if err != nil {
+			return Job{}, false, err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 1 {
+			return job, true, nil
+		}
+		// Another worker claimed it first
+	}
+	return Job{}, false, nil
+}
+
+// Ack implements Queue
+func (q *SQLQueue) Ack(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, q.opts.bind(fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, q.opts.JobsTable)), id)
+	return err
+}
+
+// Nack implements Queue
+func (q *SQLQueue) Nack(ctx context.Context, job Job, retryAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, q.opts.bind(fmt.Sprintf(
+		`UPDATE %s SET attempts = ?, last_error = ?, available_at = ?, reserved_until = NULL WHERE id = ?`,
+		q.opts.JobsTable)), job.Attempts, job.LastError, retryAt.UTC(), job.ID)
+	return err
+}
+
+// SQLDeadLetters is a DeadLetterStore stored in a database/sql table
+type SQLDeadLetters struct {
+	db   *sql.DB
+	opts SQLOptions
+}
+
+// NewSQLDeadLetters creates a dead-letter store backed by db. Call Migrate to
+// create the table.
+func NewSQLDeadLetters(db *sql.DB, opts SQLOptions) *SQLDeadLetters {
+	return &SQLDeadLetters{db: db, opts: opts.withDefaults()}
+}
+
+// Migrate creates the dead-letter table if it does not exist
+func (s *SQLDeadLetters) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(64) PRIMARY KEY,
+	type VARCHAR(64) NOT NULL,
+	payload TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	last_error TEXT NOT NULL,
+	enqueued_at TIMESTAMP NOT NULL,
+	failed_at TIMESTAMP NOT NULL
+)`, s.opts.DeadLettersTable))
+	return err
+}
+
+// Put implements DeadLetterStore
+func (s *SQLDeadLetters) Put(ctx context.Context, dl DeadLetter) error {
+	_, err := s.db.ExecContext(ctx, s.opts.bind(fmt.Sprintf(
+		`INSERT INTO %s (id, type, payload, attempts, last_error, enqueued_at, failed_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.opts.DeadLettersTable)),
+		dl.Job.ID, dl.Job.Type, string(dl.Job.Payload), dl.Job.Attempts, dl.Job.LastError, dl.Job.EnqueuedAt.UTC(), dl.FailedAt.UTC())
+	return err
+}
+
+// Get implements DeadLetterStore
+func (s *SQLDeadLetters) Get(ctx context.Context, id string) (DeadLetter, bool, error) {
+	row := s.db.QueryRowContext(ctx, s.opts.bind(fmt.Sprintf(
+		`SELECT id, type, payload, attempts, last_error, enqueued_at, failed_at FROM %s WHERE id = ?`,
+		s.opts.DeadLettersTable)), id)
+	dl, err := scanDeadLetter(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DeadLetter{}, false, nil
+	}
+	if err != nil {
+		return DeadLetter{}, false, err
+	}
+	return dl, true, nil
+}
+
+// List implements DeadLetterStore
+func (s *SQLDeadLetters) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	query := fmt.Sprintf(`SELECT id, type, payload, attempts, last_error, enqueued_at, failed_at FROM %s ORDER BY failed_at`, s.opts.DeadLettersTable)
+	if limit > 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []DeadLetter
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, dl)
+	}
+	return result, rows.Err()
+}
+
+// Delete implements DeadLetterStore
+func (s *SQLDeadLetters) Delete(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, s.opts.bind(fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, s.opts.DeadLettersTable)), id)
+	return err
+}
+
+func scanDeadLetter(row interface{ Scan(...interface{}) error }) (DeadLetter, error) {
+	var (
+		dl      DeadLetter
+		payload string
+	)
+	err := row.Scan(&dl.Job.ID, &dl.Job.Type, &payload, &dl.Job.Attempts, &dl.Job.LastError, &dl.Job.EnqueuedAt, &dl.FailedAt)
+	dl.Job.Payload = []byte(payload)
+	return dl, err
+}
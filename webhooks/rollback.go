@@ -0,0 +1,144 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+)
+
+// rollback reverses the transaction a rollback refers to, exactly once. If
+// the original has not arrived yet it is tombstoned so that it is refused
+// when it does: the referenced transaction, or else the rest of the round.
+func (r *Router) rollback(ctx context.Context, e *RollbackEvent) map[string]interface{} {
+	if _, ok, err := r.ledger.Get(ctx, TypeRollback, e.TransactionID); err != nil {
+		return r.internalError(err)
+	} else if ok {
+		return r.alreadyProcessed(ctx, e.PlayerID, e.Currency)
+	}
+
+	entry := LedgerEntry{
+		TransactionID: e.TransactionID,
+		Type:          TypeRollback,
+		PlayerID:      e.PlayerID,
+		RoundID:       e.RoundID,
+		At:            r.now(),
+	}
+	entry.Amount, _ = money.New(0, e.Currency)
+
+	target, found, err := r.resolveRollback(ctx, e)
+	if err != nil {
+		return r.internalError(err)
+	}
+
+	if !found {
+		tomb := Tombstone{PlayerID: e.PlayerID, RoundID: e.RoundID, RollbackID: e.TransactionID, At: entry.At}
+		switch {
+		case e.ReferenceTransactionID != nil:
+			tomb.TransactionID = e.ReferenceTransactionID
+		case e.RoundID == "":
+			tomb.TransactionID = &e.TransactionID
+		}
+		if err := r.ledger.Tombstone(ctx, tomb); err != nil {
+			return r.internalError(err)
+		}
+		if err := r.ledger.Put(ctx, entry); err != nil {
+			return r.internalError(err)
+		}
+		return r.balance(ctx, e.PlayerID, e.Currency)
+	}
+
+	if target.RolledBackBy != nil {
+		return r.alreadyProcessed(ctx, e.PlayerID, e.Currency)
+	}
+	marked, err := r.ledger.MarkRolledBack(ctx, target.Type, target.TransactionID, e.TransactionID)
+	if err != nil {
+		return r.internalError(err)
+	}
+	if !marked {
+		return r.alreadyProcessed(ctx, e.PlayerID, e.Currency)
+	}
+
+	var bal money.Money
+	if target.Type == TypeBet {
+		bal, err = r.wallet.Credit(ctx, e.PlayerID, target.Amount, e.TransactionID)
+	} else {
+		bal, err = r.wallet.Debit(ctx, e.PlayerID, target.Amount, e.TransactionID)
+	}
+	if err != nil {
+		// Undo the mark so a retried rollback can succeed
+		r.ledger.Put(ctx, target)
+		return r.walletError(ctx, err, e.PlayerID, e.Currency)
+	}
+
+	entry.Amount = target.Amount
+	entry.ReversedID = &target.TransactionID
+	if err := r.ledger.Put(ctx, entry); err != nil {
+		return r.internalError(err)
+	}
+
+	if r.guard != nil {
+		switch target.Type {
+		case TypeBet:
+			r.guard.AdjustLoss(ctx, e.PlayerID, target.Amount.Neg())
+		case TypeWin:
+			r.guard.AdjustLoss(ctx, e.PlayerID, target.Amount)
+		}
+	}
+	if r.rounds != nil {
+		reversed := target.Amount.Minor()
+		if target.Type != TypeBet {
+			reversed = -reversed
+		}
+		tracked := *e
+		tracked.Amount = &reversed
+		tracked.ReferenceTransactionID = &target.TransactionID
+		if tracked.RoundID == "" {
+			tracked.RoundID = target.RoundID
+		}
+		r.rounds.TrackEvent(&tracked)
+	}
+	return r.handler.SuccessResponseMoney(bal, nil)
+}
+
+// resolveRollback finds the transaction a rollback reverses: the explicit
+// reference if one is given, otherwise a bet sharing the rollback's
+// transaction ID, otherwise the latest bet of the round that has not been
+// rolled back (matching the amount when the rollback carries one). Only the
+// rollback's own player and currency are considered.
+func (r *Router) resolveRollback(ctx context.Context, e *RollbackEvent) (LedgerEntry, bool, error) {
+	owned := func(entry LedgerEntry) bool {
+		return entry.PlayerID == e.PlayerID && entry.Amount.Currency() == e.Currency
+	}
+	if e.ReferenceTransactionID != nil {
+		for _, txType := range []string{TypeBet, TypeWin, TypeReward} {
+			entry, ok, err := r.ledger.Get(ctx, txType, *e.ReferenceTransactionID)
+			if err != nil || (ok && owned(entry)) {
+				return entry, ok, err
+			}
+		}
+		return LedgerEntry{}, false, nil
+	}
+
+	if entry, ok, err := r.ledger.Get(ctx, TypeBet, e.TransactionID); err != nil || (ok && owned(entry)) {
+		return entry, ok, err
+	}
+
+	if e.RoundID == "" {
+		return LedgerEntry{}, false, nil
+	}
+	entries, err := r.ledger.Round(ctx, e.PlayerID, e.RoundID)
+	if err != nil {
+		return LedgerEntry{}, false, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		c := entries[i]
+		if c.Type != TypeBet || c.RolledBackBy != nil || !owned(c) {
+			continue
+		}
+		if e.Amount != nil && c.Amount.Minor() != *e.Amount {
+			continue
+		}
+		return c, true, nil
+	}
+	return LedgerEntry{}, false, nil
+}
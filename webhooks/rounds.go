@@ -0,0 +1,481 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoRoundID is returned when a transaction cannot be tracked because it
+// has no round ID
+var ErrNoRoundID = errors.New("transaction has no round_id")
+
+// ErrUnresolvedRollback is returned when a rollback carries no amount and
+// the transaction it reverses is not in its round
+var ErrUnresolvedRollback = errors.New("rollback does not match a tracked transaction")
+
+// Round status constants
+const (
+	RoundOpen       = "open"
+	RoundSettled    = "settled"
+	RoundRolledBack = "rolled_back"
+)
+
+// Round flag constants
+const (
+	// FlagTimedOut marks a round that stayed open longer than the tracker timeout
+	FlagTimedOut = "timed_out"
+	// FlagWinWithoutBet marks a round that received a win before any bet
+	FlagWinWithoutBet = "win_without_bet"
+)
+
+// RoundTransaction is a single bet, win or rollback recorded against a round
+type RoundTransaction struct {
+	Type          string
+	TransactionID int64
+	Amount        int64
+	At            time.Time
+	// ReversedID is the transaction a rollback reversed, when it was found
+	// in the round
+	ReversedID *int64
+}
+
+// Round aggregates the transactions of one game round
+type Round struct {
+	ID        string
+	SessionID string
+	PlayerID  string
+	Currency  string
+	GameID    *int
+	Status    string
+	Flags     []string
+
+	BetTotal int64
+	WinTotal int64
+	// RollbackTotal is what rollbacks returned to the player: positive for
+	// reversed bets, negative for reversed wins
+	RollbackTotal int64
+	Transactions  []RoundTransaction
+
+	OpenedAt  time.Time
+	UpdatedAt time.Time
+	ClosedAt  time.Time
+}
+
+// Net returns the player's result for the round in minor units: wins minus
+// bets, less anything rolled back
+func (r Round) Net() int64 {
+	return r.WinTotal + r.RollbackTotal - r.BetTotal
+}
+
+// Reversed reports whether a rollback in the round reversed the bet or win
+// with the given transaction ID
+func (r Round) Reversed(transactionID int64) bool {
+	return r.reversedIDs()[transactionID]
+}
+
+// HasFlag reports whether the round carries the given flag
+func (r Round) HasFlag(flag string) bool {
+	for _, f := range r.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStuck reports whether the round has been flagged as timed out or
+// inconsistent
+func (r Round) IsStuck() bool {
+	return len(r.Flags) > 0
+}
+
+// RoundTrackerOptions configures a RoundTracker
+type RoundTrackerOptions struct {
+	// Timeout after which an open round is flagged; defaults to 5 minutes
+	Timeout time.Duration
+	// OnStuck is called once for each flag a round receives
+	OnStuck func(round Round, flag string)
+	// Now overrides the clock, mainly for tests
+	Now func() time.Time
+}
+
+// RoundTracker ties bets, wins and rollbacks together by round ID. It is
+// safe for concurrent use.
+type RoundTracker struct {
+	mu      sync.Mutex
+	rounds  map[string]*Round
+	seen    map[string]map[int64]bool
+	timeout time.Duration
+	onStuck func(Round, string)
+	now     func() time.Time
+}
+
+// NewRoundTracker creates a new round tracker
+func NewRoundTracker(opts RoundTrackerOptions) *RoundTracker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &RoundTracker{
+		rounds:  make(map[string]*Round),
+		seen:    make(map[string]map[int64]bool),
+		timeout: opts.Timeout,
+		onStuck: opts.OnStuck,
+		now:     opts.Now,
+	}
+}
+
+// Track records a parsed payload. Payloads that are not bets, wins or
+// rollbacks are ignored.
+func (t *RoundTracker) Track(p *Payload) (Round, error) {
+	if !p.IsBet() && !p.IsWin() && !p.IsRollback() {
+		return Round{}, nil
+	}
+	event, err := p.Event()
+	if err != nil {
+		return Round{}, err
+	}
+	return t.TrackEvent(event)
+}
+
+// TrackEvent records a typed event and returns the updated round. Events
+// other than bets, wins and rollbacks are ignored. A transaction ID seen
+// before for the same round is not counted twice.
+//
+// A rollback reverses a transaction of its round the way the Router
+// resolves it: the reference if given, else a bet with the rollback's ID,
+// else the latest bet not yet reversed. A reversed bet is returned to the
+// player and a reversed win taken back. When nothing matches, the
+// rollback's Amount is counted as returned to the player, and a rollback
+// without one fails with ErrUnresolvedRollback. The round is rolled back
+// once every bet and win in it has been reversed; until then it stays open
+// or settled by what is left.
+func (t *RoundTracker) TrackEvent(event Event) (Round, error) {
+	var (
+		roundID, sessionID string
+		tx                 RoundTransaction
+		freespin           bool
+		rollback           *RollbackEvent
+	)
+	switch e := event.(type) {
+	case *BetEvent:
+		roundID, sessionID = e.RoundID, e.SessionID
+		tx = RoundTransaction{Type: TypeBet, TransactionID: e.TransactionID, Amount: e.Amount}
+	case *WinEvent:
+		roundID, sessionID = e.RoundID, e.SessionID
+		tx = RoundTransaction{Type: TypeWin, TransactionID: e.TransactionID, Amount: e.Amount}
+		freespin = e.Freespin != nil
+	case *RollbackEvent:
+		roundID, sessionID = e.RoundID, e.SessionID
+		tx = RoundTransaction{Type: TypeRollback, TransactionID: e.TransactionID}
+		rollback = e
+	default:
+		return Round{}, nil
+	}
+	if roundID == "" {
+		return Round{}, ErrNoRoundID
+	}
+
+	now := t.now()
+	tx.At = now
+
+	t.mu.Lock()
+	r, ok := t.rounds[roundID]
+	if !ok {
+		common := event.Common()
+		r = &Round{
+			ID:        roundID,
+			SessionID: sessionID,
+			PlayerID:  common.PlayerID,
+			Currency:  common.Currency,
+			GameID:    common.GameID,
+			Status:    RoundOpen,
+			OpenedAt:  now,
+		}
+		t.rounds[roundID] = r
+		t.seen[roundID] = make(map[int64]bool)
+	}
+
+	key := tx.TransactionID
+	if tx.Type == TypeRollback {
+		// Rollbacks may reuse the ID of the transaction they reverse
+		key = -key - 1
+	}
+	if t.seen[roundID][key] {
+		snapshot := r.copy()
+		t.mu.Unlock()
+		return snapshot, nil
+	}
+	if rollback != nil {
+		target, reversed, found := r.reversal(rollback)
+		switch {
+		case reversed:
+			// The target was already reversed by another rollback
+			t.seen[roundID][key] = true
+			snapshot := r.copy()
+			t.mu.Unlock()
+			return snapshot, nil
+		case found:
+			tx.ReversedID = &target.TransactionID
+			tx.Amount = target.Amount
+			if target.Type == TypeWin {
+				tx.Amount = -tx.Amount
+			}
+		case rollback.Amount != nil:
+			tx.Amount = *rollback.Amount
+		default:
+			if !ok {
+				delete(t.rounds, roundID)
+				delete(t.seen, roundID)
+			}
+			t.mu.Unlock()
+			return Round{}, ErrUnresolvedRollback
+		}
+	}
+	t.seen[roundID][key] = true
+
+	var raised []string
+	switch tx.Type {
+	case TypeBet:
+		r.BetTotal += tx.Amount
+		if r.Status != RoundOpen {
+			r.Status = RoundOpen
+			r.ClosedAt = time.Time{}
+		}
+	case TypeWin:
+		if r.BetTotal == 0 && !freespin && !r.HasFlag(FlagWinWithoutBet) {
+			r.Flags = append(r.Flags, FlagWinWithoutBet)
+			raised = append(raised, FlagWinWithoutBet)
+		}
+		r.WinTotal += tx.Amount
+		if r.Status != RoundSettled {
+			r.Status = RoundSettled
+			r.ClosedAt = now
+		}
+	case TypeRollback:
+		r.RollbackTotal += tx.Amount
+	}
+	r.Transactions = append(r.Transactions, tx)
+	r.UpdatedAt = now
+	if tx.Type == TypeRollback {
+		r.settle(now)
+	}
+
+	snapshot := r.copy()
+	t.mu.Unlock()
+
+	for _, flag := range raised {
+		t.notify(snapshot, flag)
+	}
+	return snapshot, nil
+}
+
+// settle sets the status of a round after a rollback from the bets and
+// wins it has not reversed
+func (r *Round) settle(now time.Time) {
+	done := r.reversedIDs()
+	var bets, wins int
+	for _, tx := range r.Transactions {
+		switch {
+		case done[tx.TransactionID]:
+		case tx.Type == TypeBet:
+			bets++
+		case tx.Type == TypeWin:
+			wins++
+		}
+	}
+	status := RoundOpen
+	switch {
+	case bets == 0 && wins == 0:
+		status = RoundRolledBack
+	case wins > 0:
+		status = RoundSettled
+	}
+	if status == r.Status {
+		return
+	}
+	r.Status = status
+	r.ClosedAt = time.Time{}
+	if status != RoundOpen {
+		r.ClosedAt = now
+	}
+}
+
+// reversedIDs returns the IDs of the bets and wins reversed by rollbacks
+func (r *Round) reversedIDs() map[int64]bool {
+	done := make(map[int64]bool)
+	for _, tx := range r.Transactions {
+		if tx.ReversedID != nil {
+			done[*tx.ReversedID] = true
+		}
+	}
+	return done
+}
+
+// reversal finds the transaction of the round a rollback reverses. reversed
+// reports a target that another rollback already reversed.
+func (r *Round) reversal(e *RollbackEvent) (target RoundTransaction, reversed, found bool) {
+	done := r.reversedIDs()
+	find := func(id int64, types ...string) (RoundTransaction, bool, bool) {
+		for _, txType := range types {
+			for _, tx := range r.Transactions {
+				if tx.Type == txType && tx.TransactionID == id {
+					return tx, done[id], true
+				}
+			}
+		}
+		return RoundTransaction{}, false, false
+	}
+
+	if e.ReferenceTransactionID != nil {
+		return find(*e.ReferenceTransactionID, TypeBet, TypeWin)
+	}
+	if target, reversed, found = find(e.TransactionID, TypeBet); found {
+		return target, reversed, found
+	}
+	for i := len(r.Transactions) - 1; i >= 0; i-- {
+		tx := r.Transactions[i]
+		if tx.Type != TypeBet || done[tx.TransactionID] {
+			continue
+		}
+		if e.Amount != nil && tx.Amount != *e.Amount {
+			continue
+		}
+		return tx, false, true
+	}
+	return RoundTransaction{}, false, false
+}
+
+// Round returns the round with the given ID
+func (t *RoundTracker) Round(roundID string) (Round, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r, ok := t.rounds[roundID]
+	if !ok {
+		return Round{}, false
+	}
+	return r.copy(), true
+}
+
+// Open returns all rounds that have not been settled or rolled back
+func (t *RoundTracker) Open() []Round {
+	return t.filter(func(r *Round) bool { return r.Status == RoundOpen })
+}
+
+// Stuck returns all rounds that carry at least one flag
+func (t *RoundTracker) Stuck() []Round {
+	return t.filter(func(r *Round) bool { return len(r.Flags) > 0 })
+}
+
+// ByPlayer returns all tracked rounds for a player
+func (t *RoundTracker) ByPlayer(playerID string) []Round {
+	return t.filter(func(r *Round) bool { return r.PlayerID == playerID })
+}
+
+// Check flags open rounds that have exceeded the timeout, calls OnStuck for
+// each newly flagged round and returns them
+func (t *RoundTracker) Check() []Round {
+	now := t.now()
+
+	t.mu.Lock()
+	var flagged []Round
+	for _, r := range t.rounds {
+		if r.Status != RoundOpen || r.HasFlag(FlagTimedOut) {
+			continue
+		}
+		if now.Sub(r.UpdatedAt) > t.timeout {
+			r.Flags = append(r.Flags, FlagTimedOut)
+			flagged = append(flagged, r.copy())
+		}
+	}
+	t.mu.Unlock()
+
+	sortRounds(flagged)
+	for _, r := range flagged {
+		t.notify(r, FlagTimedOut)
+	}
+	return flagged
+}
+
+// Run calls Check on every interval until the context is cancelled
+func (t *RoundTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Check()
+		}
+	}
+}
+
+// Forget removes a round from the tracker
+func (t *RoundTracker) Forget(roundID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.rounds, roundID)
+	delete(t.seen, roundID)
+}
+
+// Prune removes closed rounds that finished before the given time and
+// returns how many were removed
+func (t *RoundTracker) Prune(before time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	removed := 0
+	for id, r := range t.rounds {
+		if r.Status != RoundOpen && !r.ClosedAt.IsZero() && r.ClosedAt.Before(before) {
+			delete(t.rounds, id)
+			delete(t.seen, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (t *RoundTracker) filter(match func(*Round) bool) []Round {
+	t.mu.Lock()
+	result := make([]Round, 0)
+	for _, r := range t.rounds {
+		if match(r) {
+			result = append(result, r.copy())
+		}
+	}
+	t.mu.Unlock()
+
+	sortRounds(result)
+	return result
+}
+
+func (t *RoundTracker) notify(r Round, flag string) {
+	if t.onStuck != nil {
+		t.onStuck(r, flag)
+	}
+}
+
+func (r *Round) copy() Round {
+	c := *r
+	c.Flags = append([]string(nil), r.Flags...)
+	c.Transactions = append([]RoundTransaction(nil), r.Transactions...)
+	return c
+}
+
+func sortRounds(rounds []Round) {
+	sort.Slice(rounds, func(i, j int) bool {
+		if !rounds[i].OpenedAt.Equal(rounds[j].OpenedAt) {
+			return rounds[i].OpenedAt.Before(rounds[j].OpenedAt)
+		}
+		return rounds[i].ID < rounds[j].ID
+	})
+}
@@ -0,0 +1,414 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+	"github.com/iplaygamesai/sdk-wrapper-go/responsible"
+)
+
+// Error codes returned by the Router in addition to PLAYER_NOT_FOUND and
+// INSUFFICIENT_FUNDS
+const (
+	CodeInvalidRequest        = "INVALID_REQUEST"
+	CodeInternalError         = "INTERNAL_ERROR"
+	CodeTransactionRolledBack = "TRANSACTION_ROLLED_BACK"
+	// CodePlayBlocked is returned when a responsible-gambling policy
+	// refuses a bet
+	CodePlayBlocked = responsible.ErrorCode
+)
+
+// RouterOptions configures a Router
+type RouterOptions struct {
+	// Ledger records processed transactions; defaults to a MemoryLedger
+	Ledger Ledger
+	// Rounds, if set, is fed every committed bet, win and rollback
+	Rounds *RoundTracker
+	// Guard, if set, checks responsible-gambling policies before each bet
+	// is debited and is fed every committed bet, win and rollback
+	Guard *responsible.Guard
+	// SignatureHeader is the request header carrying the HMAC; defaults to
+	// the handler's signature headers
+	SignatureHeader string
+	// MaxBodyBytes limits the request body size; defaults to the handler's limit
+	MaxBodyBytes int64
+	// WinWait is how long a win whose round has no bet yet is held back
+	// for the bet to arrive; defaults to 2 seconds, negative disables
+	WinWait time.Duration
+	// Async, if set, queues non-balance-critical types for background
+	// processing; run the workers with Router.Async().Run
+	Async *AsyncOptions
+	// Bus, if set, receives a Message for every committed bet, win, reward
+	// and rollback
+	Bus *Bus
+	// Audit, if set, receives every verified webhook with the response sent
+	Audit AuditSink
+	// OnAuditError is called when the audit sink fails; the webhook has
+	// already been processed by then
+	OnAuditError func(error)
+	// Now overrides the clock, mainly for tests
+	Now func() time.Time
+}
+
+// Router dispatches verified webhooks to a Wallet, handling idempotency and
+// rollbacks, and builds the responses the provider expects
+type Router struct {
+	handler *Handler
+	wallet  Wallet
+	ledger  Ledger
+	rounds  *RoundTracker
+	guard   *responsible.Guard
+	headers []string
+	maxBody int64
+	async   *AsyncProcessor
+	bus     *Bus
+	locks   *keyedMutex
+	waiters *betWaiters
+	winWait time.Duration
+	audit   AuditSink
+	onAudit func(error)
+	now     func() time.Time
+}
+
+// NewRouter creates a new webhook router
+func NewRouter(handler *Handler, wallet Wallet, opts RouterOptions) *Router {
+	if opts.Ledger == nil {
+		opts.Ledger = NewMemoryLedger()
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = handler.maxBody
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.WinWait == 0 {
+		opts.WinWait = 2 * time.Second
+	}
+	r := &Router{
+		handler: handler,
+		wallet:  wallet,
+		ledger:  opts.Ledger,
+		rounds:  opts.Rounds,
+		guard:   opts.Guard,
+		maxBody: opts.MaxBodyBytes,
+		bus:     opts.Bus,
+		locks:   newKeyedMutex(),
+		waiters: newBetWaiters(),
+		winWait: opts.WinWait,
+		audit:   opts.Audit,
+		onAudit: opts.OnAuditError,
+		now:     opts.Now,
+	}
+	if opts.SignatureHeader != "" {
+		r.headers = []string{opts.SignatureHeader}
+	} else {
+		r.headers = handler.headers
+	}
+	if opts.Async != nil {
+		r.async = newAsyncProcessor(r, *opts.Async)
+	}
+	return r
+}
+
+// ServeHTTP verifies, parses and handles a webhook request
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, r.handler.ErrorResponse(CodeInvalidRequest, "Method not allowed"))
+		return
+	}
+
+	body, signature, err := r.handler.verifyRequest(req, r.headers, r.maxBody)
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		writeJSON(w, http.StatusRequestEntityTooLarge, r.handler.ErrorResponse(CodeInvalidRequest, "Body too large"))
+		return
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrMissingSignature):
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid signature"})
+		return
+	case err != nil:
+		writeJSON(w, http.StatusBadRequest, r.handler.ErrorResponse(CodeInvalidRequest, "Failed to read body"))
+		return
+	}
+
+	start := r.now()
+	status, resp := http.StatusOK, map[string]interface{}(nil)
+	payload, err := r.handler.ParseBytes(body)
+	if err != nil {
+		status, resp = http.StatusBadRequest, r.handler.ErrorResponse(CodeInvalidRequest, err.Error())
+	} else {
+		resp = r.Handle(req.Context(), payload)
+	}
+	raw := writeJSON(w, status, resp)
+
+	if r.audit != nil {
+		entry := AuditEntry{
+			Time:       start,
+			Signature:  signature,
+			Headers:    auditHeaders(req.Header),
+			Body:       body,
+			StatusCode: status,
+			Response:   raw,
+			Duration:   r.now().Sub(start),
+		}
+		if payload != nil {
+			entry.Type = payload.Type
+			entry.PlayerID = payload.PlayerID
+			entry.TransactionID = payload.TransactionID
+		}
+		if err := r.audit.Append(context.WithoutCancel(req.Context()), entry); err != nil && r.onAudit != nil {
+			r.onAudit(err)
+		}
+	}
+}
+
+// Handle processes a parsed webhook and returns the response body. Webhooks
+// for the same player are processed one at a time, so every response
+// carries the balance as of that transaction.
+func (r *Router) Handle(ctx context.Context, p *Payload) map[string]interface{} {
+	if r.async != nil && r.async.handles(p.Type) {
+		return r.async.enqueue(ctx, p)
+	}
+	return r.dispatch(ctx, p)
+}
+
+func (r *Router) dispatch(ctx context.Context, p *Payload) map[string]interface{} {
+	event, err := p.Event()
+	if err != nil {
+		return r.handler.ErrorResponse(CodeInvalidRequest, err.Error())
+	}
+
+	if win, ok := event.(*WinEvent); ok {
+		r.awaitBet(ctx, win)
+	}
+	resp, msg := r.apply(ctx, p, event)
+	// Publish outside the player lock: a Block subscriber that is backed up
+	// delays this response only, not the player's next webhook
+	if msg != nil {
+		r.bus.Publish(ctx, *msg)
+	}
+	return resp
+}
+
+// apply processes event under the player's lock and returns the response
+// with the message to publish, if any
+func (r *Router) apply(ctx context.Context, p *Payload, event Event) (map[string]interface{}, *Message) {
+	unlock := r.locks.Lock(event.Common().PlayerID)
+	defer unlock()
+
+	switch e := event.(type) {
+	case *AuthenticateEvent:
+		return r.balance(ctx, e.PlayerID, e.Currency), nil
+	case *BalanceCheckEvent:
+		return r.balance(ctx, e.PlayerID, e.Currency), nil
+	case *BetEvent:
+		return r.committed(p, e, e.TransactionID, r.bet(ctx, e))
+	case *WinEvent:
+		return r.committed(p, e, e.TransactionID, r.credit(ctx, e, TypeWin, e.TransactionID, e.Amount, e.RoundID))
+	case *RewardEvent:
+		return r.committed(p, e, e.TransactionID, r.credit(ctx, e, TypeReward, e.TransactionID, e.Amount, ""))
+	case *RollbackEvent:
+		return r.committed(p, e, e.TransactionID, r.rollback(ctx, e))
+	}
+	return r.handler.ErrorResponse(CodeInvalidRequest, "Unsupported webhook type"), nil
+}
+
+// committed passes resp through with the bus message for a committed
+// transaction. Errors and replays of already processed transactions are not
+// published.
+func (r *Router) committed(p *Payload, e Event, transactionID int64, resp map[string]interface{}) (map[string]interface{}, *Message) {
+	if r.bus == nil || resp["status"] != "success" || resp["already_processed"] == true {
+		return resp, nil
+	}
+	balance, _ := resp["balance"].(int64)
+	return resp, &Message{
+		ID:          messageID(e.EventType(), transactionID),
+		Event:       e,
+		Balance:     balance,
+		CommittedAt: r.now(),
+		Raw:         p.Raw,
+	}
+}
+
+func (r *Router) balance(ctx context.Context, playerID, currency string) map[string]interface{} {
+	bal, err := r.wallet.Balance(ctx, playerID, currency)
+	if err != nil {
+		return r.walletError(ctx, err, playerID, currency)
+	}
+	return r.handler.SuccessResponseMoney(bal, nil)
+}
+
+func (r *Router) bet(ctx context.Context, e *BetEvent) map[string]interface{} {
+	amount, err := money.New(e.Amount, e.Currency)
+	if err != nil {
+		return r.handler.ErrorResponse(CodeInvalidRequest, err.Error())
+	}
+
+	if _, ok, err := r.ledger.Get(ctx, TypeBet, e.TransactionID); err != nil {
+		return r.internalError(err)
+	} else if ok {
+		return r.alreadyProcessed(ctx, e.PlayerID, e.Currency)
+	}
+
+	// A rollback for this bet arrived first: never debit it
+	if dead, err := r.ledger.IsTombstoned(ctx, e.PlayerID, e.RoundID, e.TransactionID); err != nil {
+		return r.internalError(err)
+	} else if dead {
+		return r.rolledBack(ctx, e.PlayerID, e.Currency)
+	}
+
+	if r.guard != nil {
+		if err := r.guard.CheckBet(ctx, e.PlayerID, amount, e.Freespin != nil); err != nil {
+			return r.blocked(ctx, err, e.PlayerID, e.Currency)
+		}
+	}
+
+	bal, err := r.wallet.Debit(ctx, e.PlayerID, amount, e.TransactionID)
+	if err != nil {
+		return r.walletError(ctx, err, e.PlayerID, e.Currency)
+	}
+
+	entry := LedgerEntry{
+		TransactionID: e.TransactionID,
+		Type:          TypeBet,
+		PlayerID:      e.PlayerID,
+		RoundID:       e.RoundID,
+		Amount:        amount,
+		At:            r.now(),
+	}
+	if err := r.ledger.Put(ctx, entry); err != nil {
+		return r.internalError(err)
+	}
+	if r.guard != nil {
+		r.guard.RecordBet(ctx, e.PlayerID, amount)
+	}
+	if e.RoundID != "" {
+		r.waiters.notify(roundKey(e.PlayerID, e.RoundID))
+	}
+	r.track(e)
+	return r.handler.SuccessResponseMoney(bal, nil)
+}
+
+func (r *Router) credit(ctx context.Context, event Event, txType string, transactionID, minor int64, roundID string) map[string]interface{} {
+	common := event.Common()
+	amount, err := money.New(minor, common.Currency)
+	if err != nil {
+		return r.handler.ErrorResponse(CodeInvalidRequest, err.Error())
+	}
+
+	if _, ok, err := r.ledger.Get(ctx, txType, transactionID); err != nil {
+		return r.internalError(err)
+	} else if ok {
+		return r.alreadyProcessed(ctx, common.PlayerID, common.Currency)
+	}
+
+	// A rollback for this credit or its round arrived first: never pay it
+	if dead, err := r.ledger.IsTombstoned(ctx, common.PlayerID, roundID, transactionID); err != nil {
+		return r.internalError(err)
+	} else if dead {
+		return r.rolledBack(ctx, common.PlayerID, common.Currency)
+	}
+
+	bal, err := r.wallet.Credit(ctx, common.PlayerID, amount, transactionID)
+	if err != nil {
+		return r.walletError(ctx, err, common.PlayerID, common.Currency)
+	}
+
+	entry := LedgerEntry{
+		TransactionID: transactionID,
+		Type:          txType,
+		PlayerID:      common.PlayerID,
+		RoundID:       roundID,
+		Amount:        amount,
+		At:            r.now(),
+	}
+	if err := r.ledger.Put(ctx, entry); err != nil {
+		return r.internalError(err)
+	}
+	if r.guard != nil && txType == TypeWin {
+		r.guard.RecordWin(ctx, common.PlayerID, amount)
+	}
+	r.track(event)
+	return r.handler.SuccessResponseMoney(bal, nil)
+}
+
+func (r *Router) internalError(err error) map[string]interface{} {
+	return r.handler.ErrorResponse(CodeInternalError, err.Error())
+}
+
+// blocked reports a policy refusal with the current balance, or an internal
+// error if the guard could not decide
+func (r *Router) blocked(ctx context.Context, err error, playerID, currency string) map[string]interface{} {
+	var blocked *responsible.BlockedError
+	if !errors.As(err, &blocked) {
+		return r.internalError(err)
+	}
+	resp := r.handler.ErrorResponse(CodePlayBlocked, blocked.Error())
+	resp["policy"] = blocked.Policy
+	if !blocked.Until.IsZero() {
+		resp["blocked_until"] = blocked.Until.UTC().Format(time.RFC3339)
+	}
+	if bal, err := r.wallet.Balance(ctx, playerID, currency); err == nil {
+		resp["balance"] = bal.Minor()
+		resp["currency"] = bal.Currency()
+	}
+	return resp
+}
+
+// rolledBack refuses a transaction whose rollback arrived first, with the
+// current balance
+func (r *Router) rolledBack(ctx context.Context, playerID, currency string) map[string]interface{} {
+	bal, err := r.wallet.Balance(ctx, playerID, currency)
+	if err != nil {
+		return r.walletError(ctx, err, playerID, currency)
+	}
+	resp := r.handler.ErrorResponse(CodeTransactionRolledBack, "Transaction already rolled back")
+	resp["balance"] = bal.Minor()
+	resp["currency"] = bal.Currency()
+	return resp
+}
+
+func (r *Router) alreadyProcessed(ctx context.Context, playerID, currency string) map[string]interface{} {
+	bal, err := r.wallet.Balance(ctx, playerID, currency)
+	if err != nil {
+		return r.walletError(ctx, err, playerID, currency)
+	}
+	return r.handler.AlreadyProcessedResponseMoney(bal)
+}
+
+func (r *Router) walletError(ctx context.Context, err error, playerID, currency string) map[string]interface{} {
+	switch {
+	case errors.Is(err, ErrPlayerNotFound):
+		return r.handler.PlayerNotFoundResponse()
+	case errors.Is(err, ErrInsufficientFunds):
+		bal, balErr := r.wallet.Balance(ctx, playerID, currency)
+		if balErr != nil {
+			return r.handler.ErrorResponse("INSUFFICIENT_FUNDS", "Insufficient funds")
+		}
+		return r.handler.InsufficientFundsResponseMoney(bal)
+	}
+	return r.internalError(err)
+}
+
+func (r *Router) track(event Event) {
+	if r.rounds != nil {
+		r.rounds.TrackEvent(event)
+	}
+}
+
+// writeJSON writes body and returns the encoded bytes
+func writeJSON(w http.ResponseWriter, status int, body interface{}) []byte {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		raw, _ = json.Marshal(map[string]string{"error": "Failed to encode response"})
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(raw, '\n'))
+	return raw
+}
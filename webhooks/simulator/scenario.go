@@ -0,0 +1,261 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+)
+
+// Expect describes the response a step should produce. Zero values are not
+// checked.
+type Expect struct {
+	// HTTPStatus defaults to 200
+	HTTPStatus int
+	// Status is "success" or "error"
+	Status    string
+	ErrorCode string
+	// Balance is the exact expected balance in minor units
+	Balance *int64
+	// BalanceDelta is the expected change from the last balance seen in the
+	// scenario
+	BalanceDelta *int64
+	// RequireBalance requires an integer balance in the response
+	RequireBalance bool
+	// AlreadyProcessed requires the duplicate flag to be set
+	AlreadyProcessed bool
+}
+
+// NoSignature as a Step signature sends the webhook without a signature header
+const NoSignature = "\x00none"
+
+// Step is a single webhook in a scenario
+type Step struct {
+	Name    string
+	Webhook Webhook
+	// Signature overrides the computed signature when non-empty
+	Signature string
+	Expect    Expect
+}
+
+// Scenario is an ordered list of steps run against one endpoint
+type Scenario struct {
+	Name  string
+	Steps []Step
+}
+
+// StepResult is the outcome of one step
+type StepResult struct {
+	Step     Step
+	Result   *Result
+	Failures []string
+}
+
+// Passed reports whether the step met its expectations
+func (r StepResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Report is the outcome of a scenario
+type Report struct {
+	Scenario string
+	Steps    []StepResult
+}
+
+// Passed reports whether every step met its expectations
+func (r Report) Passed() bool {
+	for _, s := range r.Steps {
+		if !s.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// String summarises the report, one line per step
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:\n", r.Scenario)
+	for _, s := range r.Steps {
+		if s.Passed() {
+			fmt.Fprintf(&b, "  PASS %s\n", s.Step.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "  FAIL %s: %s\n", s.Step.Name, strings.Join(s.Failures, "; "))
+	}
+	return b.String()
+}
+
+// Int64 returns a pointer to v, for Expect fields
+func Int64(v int64) *int64 {
+	return &v
+}
+
+// Run sends each step in order and checks the responses. A transport error
+// stops the scenario and is returned alongside the partial report.
+func (s *Simulator) Run(ctx context.Context, sc Scenario) (Report, error) {
+	report := Report{Scenario: sc.Name}
+	var (
+		last     int64
+		haveLast bool
+	)
+
+	for _, step := range sc.Steps {
+		body := step.Webhook.Body()
+		sig := step.Signature
+		switch sig {
+		case "":
+			sig = s.Sign(body)
+		case NoSignature:
+			sig = ""
+		}
+		res, err := s.SendRaw(ctx, body, sig)
+		if res == nil {
+			report.Steps = append(report.Steps, StepResult{Step: step, Failures: []string{err.Error()}})
+			return report, fmt.Errorf("%s: %s: %w", sc.Name, step.Name, err)
+		}
+
+		sr := StepResult{Step: step, Result: res}
+		if err != nil {
+			sr.Failures = append(sr.Failures, err.Error())
+		}
+		sr.Failures = append(sr.Failures, check(step.Expect, res, last, haveLast)...)
+		report.Steps = append(report.Steps, sr)
+
+		if bal, ok := res.Balance(); ok {
+			last, haveLast = bal, true
+		}
+	}
+	return report, nil
+}
+
+func check(exp Expect, res *Result, last int64, haveLast bool) []string {
+	var failures []string
+	wantHTTP := exp.HTTPStatus
+	if wantHTTP == 0 {
+		wantHTTP = http.StatusOK
+	}
+	if res.StatusCode != wantHTTP {
+		failures = append(failures, fmt.Sprintf("HTTP status %d, want %d", res.StatusCode, wantHTTP))
+	}
+	if exp.Status != "" && res.Status() != exp.Status {
+		failures = append(failures, fmt.Sprintf("status %q, want %q", res.Status(), exp.Status))
+	}
+	if exp.ErrorCode != "" && res.ErrorCode() != exp.ErrorCode {
+		failures = append(failures, fmt.Sprintf("error_code %q, want %q", res.ErrorCode(), exp.ErrorCode))
+	}
+	if exp.AlreadyProcessed && !res.AlreadyProcessed() {
+		failures = append(failures, "already_processed not set")
+	}
+
+	if exp.RequireBalance || exp.Balance != nil || exp.BalanceDelta != nil {
+		bal, ok := res.Balance()
+		switch {
+		case !ok:
+			failures = append(failures, "response has no integer balance")
+		case exp.Balance != nil && bal != *exp.Balance:
+			failures = append(failures, fmt.Sprintf("balance %d, want %d", bal, *exp.Balance))
+		case exp.BalanceDelta != nil && !haveLast:
+			failures = append(failures, "no earlier balance to compare against")
+		case exp.BalanceDelta != nil && bal-last != *exp.BalanceDelta:
+			failures = append(failures, fmt.Sprintf("balance changed by %d, want %d", bal-last, *exp.BalanceDelta))
+		}
+	}
+	return failures
+}
+
+// RoundTrip is the basic lifecycle: authenticate, bet, win, then a second
+// bet that is rolled back
+func (s *Simulator) RoundTrip(playerID, currency string, bet, win int64) Scenario {
+	round := s.NewRoundID()
+	betWh := s.Bet(playerID, currency, bet, round)
+	second := s.Bet(playerID, currency, bet, "")
+
+	return Scenario{
+		Name: "round trip",
+		Steps: []Step{
+			{Name: "authenticate", Webhook: s.Authenticate(playerID, currency), Expect: Expect{Status: "success"}},
+			{Name: "bet", Webhook: betWh, Expect: Expect{Status: "success", BalanceDelta: Int64(-bet)}},
+			{Name: "win", Webhook: s.Win(playerID, currency, win, round), Expect: Expect{Status: "success", BalanceDelta: Int64(win)}},
+			{Name: "second bet", Webhook: second, Expect: Expect{Status: "success", BalanceDelta: Int64(-bet)}},
+			{Name: "rollback second bet", Webhook: s.Rollback(second), Expect: Expect{Status: "success", BalanceDelta: Int64(bet)}},
+			{Name: "balance check", Webhook: s.BalanceCheck(playerID, currency), Expect: Expect{Status: "success", BalanceDelta: Int64(0)}},
+		},
+	}
+}
+
+// Duplicates resends a bet, a win and a rollback and expects each repeat to
+// leave the balance unchanged
+func (s *Simulator) Duplicates(playerID, currency string, bet, win int64) Scenario {
+	round := s.NewRoundID()
+	betWh := s.Bet(playerID, currency, bet, round)
+	winWh := s.Win(playerID, currency, win, round)
+	other := s.Bet(playerID, currency, bet, "")
+	rollback := s.Rollback(other)
+
+	return Scenario{
+		Name: "duplicates",
+		Steps: []Step{
+			{Name: "balance check", Webhook: s.BalanceCheck(playerID, currency), Expect: Expect{Status: "success"}},
+			{Name: "bet", Webhook: betWh, Expect: Expect{Status: "success", BalanceDelta: Int64(-bet)}},
+			{Name: "duplicate bet", Webhook: betWh, Expect: Expect{Status: "success", BalanceDelta: Int64(0)}},
+			{Name: "win", Webhook: winWh, Expect: Expect{Status: "success", BalanceDelta: Int64(win)}},
+			{Name: "duplicate win", Webhook: winWh, Expect: Expect{Status: "success", BalanceDelta: Int64(0)}},
+			{Name: "other bet", Webhook: other, Expect: Expect{Status: "success", BalanceDelta: Int64(-bet)}},
+			{Name: "rollback", Webhook: rollback, Expect: Expect{Status: "success", BalanceDelta: Int64(bet)}},
+			{Name: "duplicate rollback", Webhook: rollback, Expect: Expect{Status: "success", BalanceDelta: Int64(0)}},
+		},
+	}
+}
+
+// OutOfOrder sends a rollback before the bet it reverses and expects the
+// late bet to be refused with TRANSACTION_ROLLED_BACK and not debited
+func (s *Simulator) OutOfOrder(playerID, currency string, bet int64) Scenario {
+	betWh := s.Bet(playerID, currency, bet, "")
+
+	return Scenario{
+		Name: "out of order",
+		Steps: []Step{
+			{Name: "balance check", Webhook: s.BalanceCheck(playerID, currency), Expect: Expect{Status: "success"}},
+			{Name: "rollback before bet", Webhook: s.Rollback(betWh), Expect: Expect{Status: "success", BalanceDelta: Int64(0)}},
+			{Name: "late bet", Webhook: betWh, Expect: Expect{Status: "error", ErrorCode: "TRANSACTION_ROLLED_BACK", BalanceDelta: Int64(0)}},
+			{Name: "balance unchanged", Webhook: s.BalanceCheck(playerID, currency), Expect: Expect{Status: "success", BalanceDelta: Int64(0)}},
+		},
+	}
+}
+
+// Freespins plays a freespin campaign of the given number of spins, each
+// winning winPerSpin, and expects only the wins to change the balance
+func (s *Simulator) Freespins(playerID, currency, freespinID string, spins int, winPerSpin int64) Scenario {
+	sc := Scenario{
+		Name: "freespins",
+		Steps: []Step{
+			{Name: "balance check", Webhook: s.BalanceCheck(playerID, currency), Expect: Expect{Status: "success"}},
+		},
+	}
+	scale := 100.0
+	if exp, err := money.Exponent(currency); err == nil {
+		scale = math.Pow10(exp)
+	}
+	var total int64
+	for spin := 1; spin <= spins; spin++ {
+		round := s.NewRoundID()
+		total += winPerSpin
+		sc.Steps = append(sc.Steps,
+			Step{
+				Name:    fmt.Sprintf("freespin %d bet", spin),
+				Webhook: s.FreespinBet(playerID, currency, freespinID, spin, spins, round),
+				Expect:  Expect{Status: "success", BalanceDelta: Int64(0)},
+			},
+			Step{
+				Name:    fmt.Sprintf("freespin %d win", spin),
+				Webhook: s.FreespinWin(playerID, currency, freespinID, spin, spins, winPerSpin, round, float64(total)/scale),
+				Expect:  Expect{Status: "success", BalanceDelta: Int64(winPerSpin)},
+			},
+		)
+	}
+	return sc
+}
@@ -0,0 +1,327 @@
+// Package simulator builds, signs and sends provider-style webhooks so that
+// an operator endpoint can be exercised without the provider
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/webhooks"
+)
+
+// Options configures a Simulator
+type Options struct {
+	// Secret is the webhook secret shared with the endpoint
+	Secret string
+	// URL is the endpoint to send webhooks to
+	URL string
+	// Handler, if set, receives webhooks in-process instead of URL
+	Handler http.Handler
+	// HTTPClient is used for URL targets; defaults to a client with a 10s timeout
+	HTTPClient *http.Client
+	// SignatureHeader defaults to X-Signature
+	SignatureHeader string
+	// SignatureEncoding defaults to hex
+	SignatureEncoding webhooks.SignatureEncoding
+	// FirstTransactionID is the first generated transaction ID; defaults to
+	// the current Unix time in milliseconds so runs do not collide
+	FirstTransactionID int64
+	// GameID and GameType are sent on every webhook; default to 1 and "slot"
+	GameID   int
+	GameType string
+	// Now overrides the clock used for timestamps
+	Now func() time.Time
+}
+
+// Simulator builds and sends signed webhooks
+type Simulator struct {
+	handler  *webhooks.Handler
+	url      string
+	target   http.Handler
+	client   *http.Client
+	header   string
+	nextID   atomic.Int64
+	nextSeq  atomic.Int64
+	gameID   int
+	gameType string
+	now      func() time.Time
+}
+
+// New creates a new simulator
+func New(opts Options) *Simulator {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.SignatureHeader == "" {
+		opts.SignatureHeader = "X-Signature"
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.FirstTransactionID <= 0 {
+		opts.FirstTransactionID = opts.Now().UnixMilli()
+	}
+	if opts.GameID <= 0 {
+		opts.GameID = 1
+	}
+	if opts.GameType == "" {
+		opts.GameType = "slot"
+	}
+
+	handler := webhooks.NewHandler(opts.Secret)
+	handler.SetSignatureEncoding(opts.SignatureEncoding)
+
+	s := &Simulator{
+		handler:  handler,
+		url:      opts.URL,
+		target:   opts.Handler,
+		client:   opts.HTTPClient,
+		header:   opts.SignatureHeader,
+		gameID:   opts.GameID,
+		gameType: opts.GameType,
+		now:      opts.Now,
+	}
+	s.nextID.Store(opts.FirstTransactionID)
+	return s
+}
+
+// Webhook is a payload ready to be signed and sent
+type Webhook struct {
+	Fields map[string]interface{}
+}
+
+// With returns a copy of the webhook with a field set, or removed when the
+// value is nil
+func (w Webhook) With(key string, value interface{}) Webhook {
+	fields := make(map[string]interface{}, len(w.Fields)+1)
+	for k, v := range w.Fields {
+		fields[k] = v
+	}
+	if value == nil {
+		delete(fields, key)
+	} else {
+		fields[key] = value
+	}
+	return Webhook{Fields: fields}
+}
+
+// Type returns the webhook type
+func (w Webhook) Type() string {
+	s, _ := w.Fields["type"].(string)
+	return s
+}
+
+// TransactionID returns the transaction ID, or 0 if the webhook has none
+func (w Webhook) TransactionID() int64 {
+	id, _ := w.Fields["transaction_id"].(int64)
+	return id
+}
+
+// Body returns the JSON encoding of the webhook
+func (w Webhook) Body() []byte {
+	body, _ := json.Marshal(w.Fields)
+	return body
+}
+
+// NextTransactionID reserves a new transaction ID
+func (s *Simulator) NextTransactionID() int64 {
+	return s.nextID.Add(1) - 1
+}
+
+// NewRoundID returns a new unique round ID
+func (s *Simulator) NewRoundID() string {
+	return fmt.Sprintf("sim_round_%d_%d", s.now().UnixNano(), s.nextSeq.Add(1))
+}
+
+// Sign returns the signature for a body using the configured secret
+func (s *Simulator) Sign(body []byte) string {
+	return s.handler.SignBytes(body)
+}
+
+func (s *Simulator) base(webhookType, playerID, currency string) Webhook {
+	return Webhook{Fields: map[string]interface{}{
+		"type":       webhookType,
+		"player_id":  playerID,
+		"currency":   currency,
+		"timestamp":  s.now().UTC().Format(time.RFC3339),
+		"game_id":    s.gameID,
+		"game_type":  s.gameType,
+		"session_id": "sim_session_" + playerID,
+	}}
+}
+
+func (s *Simulator) transaction(webhookType, playerID, currency string, amount int64, roundID string) Webhook {
+	if roundID == "" {
+		roundID = s.NewRoundID()
+	}
+	return s.base(webhookType, playerID, currency).
+		With("transaction_id", s.NextTransactionID()).
+		With("amount", amount).
+		With("round_id", roundID)
+}
+
+// Authenticate builds an authenticate webhook
+func (s *Simulator) Authenticate(playerID, currency string) Webhook {
+	return s.base(webhooks.TypeAuthenticate, playerID, currency)
+}
+
+// BalanceCheck builds a balance_check webhook
+func (s *Simulator) BalanceCheck(playerID, currency string) Webhook {
+	return s.base(webhooks.TypeBalanceCheck, playerID, currency)
+}
+
+// Bet builds a bet webhook; an empty round ID starts a new round
+func (s *Simulator) Bet(playerID, currency string, amount int64, roundID string) Webhook {
+	return s.transaction(webhooks.TypeBet, playerID, currency, amount, roundID)
+}
+
+// Win builds a win webhook for a round
+func (s *Simulator) Win(playerID, currency string, amount int64, roundID string) Webhook {
+	return s.transaction(webhooks.TypeWin, playerID, currency, amount, roundID)
+}
+
+// Rollback builds a rollback webhook reversing the given transaction
+func (s *Simulator) Rollback(original Webhook) Webhook {
+	roundID, _ := original.Fields["round_id"].(string)
+	playerID, _ := original.Fields["player_id"].(string)
+	currency, _ := original.Fields["currency"].(string)
+	return s.base(webhooks.TypeRollback, playerID, currency).
+		With("transaction_id", s.NextTransactionID()).
+		With("reference_transaction_id", original.TransactionID()).
+		With("amount", original.Fields["amount"]).
+		With("round_id", roundID)
+}
+
+// Reward builds a reward webhook
+func (s *Simulator) Reward(playerID, currency string, amount int64, rewardType, rewardTitle string) Webhook {
+	return s.base(webhooks.TypeReward, playerID, currency).
+		With("transaction_id", s.NextTransactionID()).
+		With("amount", amount).
+		With("reward_type", rewardType).
+		With("reward_title", rewardTitle)
+}
+
+// FreespinBet builds the zero-stake bet of freespin number spin out of total
+func (s *Simulator) FreespinBet(playerID, currency, freespinID string, spin, total int, roundID string) Webhook {
+	return s.freespin(s.Bet(playerID, currency, 0, roundID), freespinID, spin, total, nil)
+}
+
+// FreespinWin builds the win of freespin number spin out of total, with the
+// campaign's running winnings in major units
+func (s *Simulator) FreespinWin(playerID, currency, freespinID string, spin, total int, amount int64, roundID string, totalWinnings float64) Webhook {
+	return s.freespin(s.Win(playerID, currency, amount, roundID), freespinID, spin, total, &totalWinnings)
+}
+
+func (s *Simulator) freespin(w Webhook, freespinID string, spin, total int, totalWinnings *float64) Webhook {
+	w = w.With("is_freespin", true).
+		With("freespin_id", freespinID).
+		With("freespin_total", total).
+		With("freespins_remaining", total-spin).
+		With("freespin_round_number", spin)
+	if totalWinnings != nil {
+		w = w.With("freespin_total_winnings", *totalWinnings)
+	}
+	return w
+}
+
+// Result is the endpoint's answer to one webhook
+type Result struct {
+	StatusCode int
+	Body       map[string]interface{}
+	Raw        []byte
+	Duration   time.Duration
+}
+
+// Status returns the status field of the response body
+func (r *Result) Status() string {
+	s, _ := r.Body["status"].(string)
+	return s
+}
+
+// ErrorCode returns the error_code field of the response body
+func (r *Result) ErrorCode() string {
+	s, _ := r.Body["error_code"].(string)
+	return s
+}
+
+// Balance returns the balance field of the response body in minor units.
+// It returns false if the field is missing or not an integer.
+func (r *Result) Balance() (int64, bool) {
+	switch v := r.Body["balance"].(type) {
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// AlreadyProcessed reports whether the response flags a duplicate
+func (r *Result) AlreadyProcessed() bool {
+	v, _ := r.Body["already_processed"].(bool)
+	return v
+}
+
+// Send signs and sends a webhook
+func (s *Simulator) Send(ctx context.Context, w Webhook) (*Result, error) {
+	body := w.Body()
+	return s.SendRaw(ctx, body, s.Sign(body))
+}
+
+// SendRaw sends a body with an explicit signature, which may be wrong on
+// purpose
+func (s *Simulator) SendRaw(ctx context.Context, body []byte, signature string) (*Result, error) {
+	url := s.url
+	if s.target != nil {
+		url = "http://simulator.local/webhook"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if signature != "" {
+		req.Header.Set(s.header, signature)
+	}
+
+	start := time.Now()
+	var (
+		status int
+		raw    []byte
+	)
+	if s.target != nil {
+		rec := httptest.NewRecorder()
+		s.target.ServeHTTP(rec, req)
+		status, raw = rec.Code, rec.Body.Bytes()
+	} else {
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if raw, err = io.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+		status = resp.StatusCode
+	}
+
+	result := &Result{StatusCode: status, Raw: raw, Duration: time.Since(start)}
+	if len(bytes.TrimSpace(raw)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&result.Body); err != nil {
+			return result, fmt.Errorf("response is not a JSON object: %w", err)
+		}
+	}
+	return result, nil
+}
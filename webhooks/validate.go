@@ -0,0 +1,157 @@
+package webhooks
+
+import (
+	"strings"
+	"time"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+)
+
+// ValidationMode controls how Handler.Parse treats payloads that fail their
+// type's schema
+type ValidationMode int
+
+const (
+	// Lenient parses any JSON object and leaves validation to Payload.Validate
+	// and Payload.Event. This is the default.
+	Lenient ValidationMode = iota
+	// Strict makes Parse return a *ValidationError for invalid payloads
+	Strict
+)
+
+// ValidationError lists every field of a webhook that failed validation
+type ValidationError struct {
+	Type   string
+	Fields []*FieldError
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 1 {
+		return e.Fields[0].Error()
+	}
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Reason
+	}
+	name := e.Type
+	if name == "" {
+		name = "untyped"
+	}
+	return name + " webhook: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the field errors, so errors.As finds the first *FieldError
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, f := range e.Fields {
+		errs[i] = f
+	}
+	return errs
+}
+
+// Field returns the error for the named field, or nil if it passed
+func (e *ValidationError) Field(name string) *FieldError {
+	for _, f := range e.Fields {
+		if f.Field == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// SetValidationMode switches between Lenient and Strict parsing. Set it
+// before the handler is shared between goroutines.
+func (h *Handler) SetValidationMode(mode ValidationMode) {
+	h.mode = mode
+}
+
+// ValidationMode returns the handler's validation mode
+func (h *Handler) ValidationMode() ValidationMode {
+	return h.mode
+}
+
+// Validate checks the payload against the schema of its type: required
+// fields, non-negative amounts and counters, an ISO 4217 currency and an
+// RFC 3339 timestamp. Types the SDK does not know only get the format checks.
+// The result is nil or a *ValidationError.
+func (p *Payload) Validate() error {
+	if v := p.validate(true); v != nil {
+		return v
+	}
+	return nil
+}
+
+// validate collects field errors; formats adds the currency, timestamp and
+// counter checks on top of the required fields Event needs
+func (p *Payload) validate(formats bool) *ValidationError {
+	v := &ValidationError{Type: p.Type}
+	add := func(field, reason string) {
+		v.Fields = append(v.Fields, &FieldError{Type: p.Type, Field: field, Reason: reason})
+	}
+
+	known := false
+	switch p.Type {
+	case TypeAuthenticate, TypeBalanceCheck, TypeBet, TypeWin, TypeRollback, TypeReward:
+		known = true
+	case "":
+		add("type", "is required")
+	}
+
+	if known && p.PlayerID == "" {
+		add("player_id", "is required")
+	}
+	if known && p.Type != TypeAuthenticate && p.Currency == "" {
+		add("currency", "is required")
+	}
+	if formats && p.Currency != "" && !money.IsKnown(p.Currency) {
+		add("currency", "is not an ISO 4217 currency code")
+	}
+	if formats && p.Timestamp != "" {
+		if _, err := time.Parse(time.RFC3339, p.Timestamp); err != nil {
+			add("timestamp", "is not an RFC 3339 timestamp")
+		}
+	}
+
+	switch p.Type {
+	case TypeBet, TypeWin, TypeReward:
+		if p.TransactionID == nil {
+			add("transaction_id", "is required")
+		}
+		if p.Amount == nil {
+			add("amount", "is required")
+		} else if *p.Amount < 0 {
+			add("amount", "must not be negative")
+		}
+		if p.Type == TypeReward && p.RewardType == "" {
+			add("reward_type", "is required")
+		}
+	case TypeRollback:
+		if p.TransactionID == nil {
+			add("transaction_id", "is required")
+		}
+		if p.Amount != nil && *p.Amount < 0 {
+			add("amount", "must not be negative")
+		}
+	}
+
+	if formats {
+		for _, c := range []struct {
+			field string
+			value *int
+		}{
+			{"freespin_total", p.FreespinTotal},
+			{"freespins_remaining", p.FreespinsRemaining},
+			{"freespin_round_number", p.FreespinRoundNumber},
+		} {
+			if c.value != nil && *c.value < 0 {
+				add(c.field, "must not be negative")
+			}
+		}
+	}
+
+	if len(v.Fields) == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,191 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Defaults for NewHandler
+const (
+	DefaultSignatureHeader = "X-Signature"
+	DefaultMaxBodyBytes    = 1 << 20
+)
+
+// Verification errors
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrBodyTooLarge     = errors.New("webhook body too large")
+)
+
+// SignatureEncoding is how the HMAC-SHA256 is written in the signature header
+type SignatureEncoding int
+
+const (
+	// EncodingHex is lowercase or uppercase hex, the default
+	EncodingHex SignatureEncoding = iota
+	// EncodingBase64 is standard or URL-safe base64, padded or not
+	EncodingBase64
+)
+
+// SetSignatureHeaders sets the headers VerifyRequest reads the signature
+// from; the first one present wins. Defaults to X-Signature.
+func (h *Handler) SetSignatureHeaders(names ...string) {
+	if len(names) == 0 {
+		names = []string{DefaultSignatureHeader}
+	}
+	h.headers = names
+}
+
+// SetSignatureEncoding sets the encoding Sign produces and Verify expects
+func (h *Handler) SetSignatureEncoding(enc SignatureEncoding) {
+	h.encoding = enc
+}
+
+// SetMaxBodyBytes limits the body VerifyReader and VerifyRequest will read;
+// defaults to 1 MiB
+func (h *Handler) SetMaxBodyBytes(n int64) {
+	if n <= 0 {
+		n = DefaultMaxBodyBytes
+	}
+	h.maxBody = n
+}
+
+// SignBytes computes the signature the provider sends for a raw body
+func (h *Handler) SignBytes(body []byte) string {
+	sum := h.mac(body)
+	if h.encoding == EncodingBase64 {
+		return base64.StdEncoding.EncodeToString(sum)
+	}
+	return hex.EncodeToString(sum)
+}
+
+// VerifyBytes verifies a signature against the exact raw body. A "sha256="
+// prefix on the signature is accepted.
+func (h *Handler) VerifyBytes(body []byte, signature string) bool {
+	sig, ok := h.decodeSignature(signature)
+	if !ok {
+		return false
+	}
+	return hmac.Equal(h.mac(body), sig)
+}
+
+// VerifyReader reads a body of at most the configured size from r, verifies
+// it and returns the raw bytes for parsing
+func (h *Handler) VerifyReader(r io.Reader, signature string) ([]byte, error) {
+	body, err := h.readBody(r, h.maxBody)
+	if err != nil {
+		return nil, err
+	}
+	if signature == "" {
+		return nil, ErrMissingSignature
+	}
+	if !h.VerifyBytes(body, signature) {
+		return nil, ErrInvalidSignature
+	}
+	return body, nil
+}
+
+// VerifyRequest verifies an incoming webhook request against its exact raw
+// body and returns that body. req.Body is replaced with a fresh reader over
+// the same bytes, so it can be read again afterwards.
+func (h *Handler) VerifyRequest(req *http.Request) ([]byte, error) {
+	body, _, err := h.verifyRequest(req, h.headers, h.maxBody)
+	return body, err
+}
+
+// ParseRequest verifies and parses an incoming webhook request
+func (h *Handler) ParseRequest(req *http.Request) (*Payload, error) {
+	body, err := h.VerifyRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return h.ParseBytes(body)
+}
+
+// VerifyAndParseBytes verifies and parses a raw body in one step
+func (h *Handler) VerifyAndParseBytes(body []byte, signature string) (*Payload, error) {
+	if !h.VerifyBytes(body, signature) {
+		return nil, ErrInvalidSignature
+	}
+	return h.ParseBytes(body)
+}
+
+// SignatureFromRequest returns the value of the first configured signature
+// header present on req
+func (h *Handler) SignatureFromRequest(req *http.Request) string {
+	return signatureHeader(req, h.headers)
+}
+
+func (h *Handler) verifyRequest(req *http.Request, headers []string, maxBody int64) ([]byte, string, error) {
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+	body, err := h.readBody(req.Body, maxBody)
+	req.Body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	signature := signatureHeader(req, headers)
+	if signature == "" {
+		return body, "", ErrMissingSignature
+	}
+	if !h.VerifyBytes(body, signature) {
+		return body, signature, ErrInvalidSignature
+	}
+	return body, signature, nil
+}
+
+func (h *Handler) readBody(r io.Reader, maxBody int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, maxBody+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading webhook body: %w", err)
+	}
+	if int64(len(body)) > maxBody {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrBodyTooLarge, maxBody)
+	}
+	return body, nil
+}
+
+func (h *Handler) mac(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(h.secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func (h *Handler) decodeSignature(signature string) ([]byte, bool) {
+	signature = strings.TrimSpace(signature)
+	if len(signature) > 7 && strings.EqualFold(signature[:7], "sha256=") {
+		signature = signature[7:]
+	}
+
+	if h.encoding == EncodingBase64 {
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if sig, err := enc.DecodeString(signature); err == nil {
+				return sig, true
+			}
+		}
+		return nil, false
+	}
+	sig, err := hex.DecodeString(signature)
+	return sig, err == nil
+}
+
+func signatureHeader(req *http.Request, headers []string) string {
+	for _, name := range headers {
+		if v := req.Header.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
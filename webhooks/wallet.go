@@ -0,0 +1,25 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iplaygamesai/sdk-wrapper-go/money"
+)
+
+var (
+	// ErrPlayerNotFound should be returned by a Wallet for unknown players
+	ErrPlayerNotFound = errors.New("player not found")
+
+	// ErrInsufficientFunds should be returned by Wallet.Debit when the
+	// balance does not cover the amount
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// Wallet is implemented by the operator to hold player balances. Debit and
+// Credit return the balance after the operation.
+type Wallet interface {
+	Balance(ctx context.Context, playerID, currency string) (money.Money, error)
+	Debit(ctx context.Context, playerID string, amount money.Money, transactionID int64) (money.Money, error)
+	Credit(ctx context.Context, playerID string, amount money.Money, transactionID int64) (money.Money, error)
+}